@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cascades-fbp/cascades/graph"
+	"github.com/cascades-fbp/cascades/library"
+	"github.com/cascades-fbp/cascades/lint"
+	"github.com/codegangsta/cli"
+)
+
+// Renders a given graph as a diagram
+func renderGraph(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		fmt.Printf("Incorrect Usage. You need to provide a path to a graph as argument!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	g, err := graph.ParseFile(c.Args().First())
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	diagram := graph.NewDiagram(g)
+
+	if c.Bool("subgraphs") {
+		db, err := library.Open(c.GlobalString("file"))
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		dir, err := filepath.Abs(filepath.Dir(c.Args().First()))
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		resolver := newResolver(c)
+		if err = expandSubgraphs(diagram, dir, db, resolver); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	switch c.String("format") {
+	case "dot":
+		err = diagram.WriteDOT(os.Stdout)
+	case "mermaid":
+		err = diagram.WriteMermaid(os.Stdout)
+	default:
+		err = fmt.Errorf("Unsupported format %s (should be dot or mermaid)", c.String("format"))
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// Checks a given graph for suspicious patterns
+func lintGraph(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		fmt.Printf("Incorrect Usage. You need to provide a path to a graph as argument!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	g, err := graph.ParseFile(c.Args().First())
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// lint without library checks if there is no library
+	r, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		if !c.Bool("json") {
+			fmt.Printf("WARNING %s (checking graph structure only)\n", err.Error())
+		}
+		r = nil
+	}
+
+	issues := lint.Lint(g, r)
+	if c.Bool("json") {
+		data, err := json.MarshalIndent(issues, "", "   ")
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, i := range issues {
+			fmt.Println(i.String())
+		}
+		if len(issues) == 0 {
+			fmt.Println("No issues found")
+		}
+	}
+
+	if lint.HasErrors(issues) || (c.Bool("strict") && len(issues) > 0) {
+		os.Exit(1)
+	}
+}
+
+// Prints a structural difference between two graphs
+func diffGraphs(c *cli.Context) {
+	if len(c.Args()) != 2 {
+		fmt.Printf("Incorrect Usage. You need to provide paths to two graphs as arguments!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	from, err := graph.ParseFile(c.Args()[0])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	to, err := graph.ParseFile(c.Args()[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	diff := graph.Compare(from, to)
+	if c.Bool("json") {
+		data, err := json.MarshalIndent(diff, "", "   ")
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(diff.String())
+	}
+
+	// exit with non-zero status like diff(1) does
+	if !diff.Empty() {
+		os.Exit(1)
+	}
+}
+
+// Performs three-way merge of the graphs and prints the result in JSON
+func mergeGraphs(c *cli.Context) {
+	if len(c.Args()) != 3 {
+		fmt.Printf("Incorrect Usage. You need to provide paths to base, our and their graphs as arguments!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	graphs := []*graph.Description{}
+	for _, path := range c.Args() {
+		g, err := graph.ParseFile(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+		graphs = append(graphs, g)
+	}
+
+	merged, conflicts := graph.Merge(graphs[0], graphs[1], graphs[2])
+	data, err := json.MarshalIndent(merged, "", "   ")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	if c.String("output") != "" {
+		err = ioutil.WriteFile(c.String("output"), data, os.FileMode(0644))
+		if err != nil {
+			fmt.Printf("Failed to save merged graph: %s\n", err.Error())
+			os.Exit(2)
+		}
+	} else {
+		fmt.Println(string(data))
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Fprintln(os.Stderr, conflict.String())
+	}
+	if len(conflicts) > 0 {
+		os.Exit(1)
+	}
+}
+
+// expandSubgraphs loads subgraphs of all composite processes in a diagram
+// recursively so they are rendered as clusters
+func expandSubgraphs(d *graph.Diagram, dir string, r library.Registrar, resolver *library.Resolver) error {
+	for name, p := range d.Graph.Processes {
+		ref, err := p.ComponentRef()
+		if err != nil {
+			return err
+		}
+		entry, err := r.Get(ref)
+		if err != nil {
+			return fmt.Errorf("Component %s not found in the library", ref)
+		}
+		if !graph.IsGraphFile(entry.Executable) {
+			continue
+		}
+		path, err := resolver.Resolve(entry.Executable, dir)
+		if err != nil {
+			return err
+		}
+		g, err := graph.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		sub := graph.NewDiagram(g)
+		if err = expandSubgraphs(sub, filepath.Dir(path), r, resolver); err != nil {
+			return err
+		}
+		d.Subgraphs[name] = sub
+	}
+	return nil
+}
@@ -5,39 +5,38 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/cascades-fbp/cascades/docs"
 	"github.com/cascades-fbp/cascades/graph"
 	"github.com/cascades-fbp/cascades/library"
+	"github.com/cascades-fbp/cascades/log"
 	"github.com/codegangsta/cli"
 )
 
 // Print catalog command
 func listLibrary(c *cli.Context) {
-	// read components library file if exists
-	data, err := ioutil.ReadFile(c.GlobalString("file"))
-	if err != nil && os.IsExist(err) {
-		fmt.Printf("Failed to read catalogue file: %s", err.Error())
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
 		return
 	}
-	if data == nil {
+
+	entries := db.List()
+	if len(entries) == 0 {
 		fmt.Println("Library is empty")
 		return
 	}
-
-	// create JSON library (the only implementation for now)
-	var db library.JSONLibrary
-	err = json.Unmarshal(data, &db)
-	if err != nil {
-		fmt.Printf("Library file is invalid: %s", err.Error())
-		return
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
 	}
-
-	for _, e := range db.List() {
-		fmt.Println(e.Name)
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(name)
 	}
 }
 
@@ -48,32 +47,25 @@ func infoFromLibrary(c *cli.Context) {
 		return
 	}
 
-	// read components library file if exists
-	data, err := ioutil.ReadFile(c.GlobalString("file"))
-	if err != nil && os.IsExist(err) {
-		fmt.Printf("Failed to read catalogue file: %s", err.Error())
-		return
-	}
-	if data == nil {
-		fmt.Println("Library is empty")
-		return
-	}
-
-	// create JSON library (the only implementation for now)
-	var db library.JSONLibrary
-	err = json.Unmarshal(data, &db)
+	db, err := library.Open(c.GlobalString("file"))
 	if err != nil {
-		fmt.Printf("Library file is invalid: %s", err.Error())
+		fmt.Println(err.Error())
 		return
 	}
 
 	component := strings.ToLower(c.Args().First())
-	for _, e := range db.List() {
-		if e.Name != component {
-			continue
-		}
+	if e, err := db.Get(component); err != nil {
+		fmt.Printf("Component %s not found in the library\n", component)
+	} else {
 		fmt.Printf("NAME:\n    %s\n", e.Name)
+		if versions := library.Versions(db, e.Name); len(versions) > 0 {
+			fmt.Printf("VERSION:\n    %s\n", e.Version)
+			fmt.Printf("AVAILABLE VERSIONS:\n    %s\n", strings.Join(versions, ", "))
+		}
 		fmt.Printf("LOCATION:\n    %s\n", e.Executable)
+		if path, err := newResolver(c).Resolve(e.Executable); err == nil && path != e.Executable {
+			fmt.Printf("    (resolved to %s)\n", path)
+		}
 		fmt.Println("INPUTS:")
 		if len(e.Inports) == 0 {
 			fmt.Println("    None")
@@ -92,7 +84,6 @@ func infoFromLibrary(c *cli.Context) {
 
 		}
 		fmt.Printf("DESCRIPTION:\n    %s\n", e.Description)
-		break
 	}
 }
 
@@ -105,23 +96,21 @@ func addToLibrary(c *cli.Context) {
 	}
 
 	// read components library file if exists
-	data, err := ioutil.ReadFile(c.GlobalString("file"))
-	if err != nil && os.IsExist(err) {
-		fmt.Printf("Failed to read catalogue file: %s", err.Error())
+	file, err := libraryFile(c)
+	if err != nil {
+		fmt.Println(err.Error())
 		return
 	}
-
-	// create JSON library (the only implementation for now)
-	var db library.JSONLibrary
-	if data != nil {
-		err = json.Unmarshal(data, &db)
-	}
-	if err != nil {
-		db = library.JSONLibrary{
+	var db *library.JSONLibrary
+	if _, err = os.Stat(file); os.IsNotExist(err) {
+		db = &library.JSONLibrary{
 			Entries: make(map[string]library.Entry),
 		}
 		db.Name = "Local Components Library"
 		db.Created = time.Now()
+	} else if db, err = library.ReadJSONLibrary(file); err != nil {
+		fmt.Println(err.Error())
+		return
 	}
 
 	info, err := os.Stat(c.Args().First())
@@ -155,20 +144,13 @@ func addToLibrary(c *cli.Context) {
 	}
 
 	// write index back or create if not exists
-	db.Updated = time.Now()
-	result, err := db.JSON()
-	if err != nil {
-		fmt.Printf("Failed to generate JSON: %s", err.Error())
-		return
-	}
-	err = ioutil.WriteFile(c.GlobalString("file"), result, os.FileMode(0644))
-	if err != nil {
-		fmt.Printf("Failed to save registry file: %s", err.Error())
+	if err = writeLibrary(file, db); err != nil {
+		fmt.Println(err.Error())
 		return
 	}
 }
 
-func addDirToLibrary(c *cli.Context, r library.Registrar, dir string) {
+func addDirToLibrary(c *cli.Context, r *library.JSONLibrary, dir string) {
 	fmt.Printf("Walking components directory: %s\n", dir)
 	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -178,14 +160,7 @@ func addDirToLibrary(c *cli.Context, r library.Registrar, dir string) {
 		if info.IsDir() {
 			return nil
 		}
-		sep := string(filepath.Separator)
-		name := strings.TrimPrefix(strings.TrimPrefix(path, dir), sep)
-		name = strings.TrimPrefix(name, sep)
-		name = strings.Replace(name, sep, "/", 1)
-		name = strings.TrimSuffix(name, ".fbp")
-		name = strings.TrimSuffix(name, ".json")
-		name = strings.TrimSuffix(name, ".exe")
-		err = addFileToLibrary(c, r, path, name)
+		err = addFileToLibrary(c, r, path, library.EntryName(dir, path))
 		if err != nil {
 			fmt.Printf("Error adding to registry: %s", err.Error())
 		}
@@ -195,104 +170,422 @@ func addDirToLibrary(c *cli.Context, r library.Registrar, dir string) {
 	fmt.Println("DONE")
 }
 
-func addFileToLibrary(c *cli.Context, r library.Registrar, file string, name string) error {
+func addFileToLibrary(c *cli.Context, r *library.JSONLibrary, file string, name string) error {
 	var entry *library.Entry
-	if strings.HasSuffix(file, ".fbp") {
-		// adding a compsite component (subgraph) in .fbp format
-		data, err := ioutil.ReadFile(file)
-		if err != nil {
-			return err
-		}
-		g, err := graph.ParseFBP(data)
+	if graph.IsGraphFile(file) {
+		// adding a compsite component (subgraph) in .fbp or .json format
+		g, err := graph.ParseFile(file)
 		if err != nil {
 			return err
 		}
-		if entry, err = graphToEntry(g, file, r); err != nil {
+		if entry, err = library.GraphEntry(g, file, r); err != nil {
 			return err
 		}
-		entry.Elementary = false
-
-	} else if strings.HasSuffix(file, ".json") {
-		// adding a compsite component (subgraph) in .json format
-		data, err := ioutil.ReadFile(file)
-		if err != nil {
-			return err
-		}
-		g, err := graph.ParseJSON(data)
-		if err != nil {
-			return err
-		}
-		if entry, err = graphToEntry(g, file, r); err != nil {
-			return err
-		}
-		entry.Elementary = false
 
 	} else {
 		// adding an elementary component
-		c := exec.Command(file, "--json")
-		out, err := c.Output()
-		if err != nil {
+		var err error
+		if entry, err = library.Introspect(file); err != nil {
 			return fmt.Errorf("Cannot register component %s: %s\n", name, err.Error())
 		}
-		if err = json.Unmarshal(out, &entry); err != nil {
-			return err
+		// remember the executable to detect changes later
+		if err = entry.Stamp(file); err != nil {
+			return fmt.Errorf("Cannot register component %s: %s\n", name, err.Error())
 		}
-		entry.Elementary = true
 	}
 
 	if len(entry.Inports) == 0 && len(entry.Outports) == 0 {
 		return fmt.Errorf("Cannot register component %s: inports and outports are empty", name)
 	}
 
+	path, err := executablePath(c, file)
+	if err != nil {
+		return err
+	}
+	// version may be given with the name, otherwise reported by the component
+	name, version := library.SplitVersion(name)
+	if version != "" {
+		entry.Version = version
+	}
 	entry.Name = name
-	entry.Executable = file
-	if r.Exists(name) && !c.Bool("force") {
-		fmt.Printf("WARNING \"%s\" already exists and --force is not provided. Ignoring this entry", name)
+	entry.Executable = path
+	if r.Has(entry.Key()) && !c.Bool("force") {
+		fmt.Printf("WARNING \"%s\" already exists and --force is not provided. Ignoring this entry", entry.Key())
 		fmt.Println("")
 	} else {
 		r.Add(*entry)
-		fmt.Printf("Added \"%s\"", name)
+		fmt.Printf("Added \"%s\"", entry.Key())
 		fmt.Println("")
 	}
 
 	return nil
 }
 
-func graphToEntry(g *graph.Description, path string, r library.Registrar) (*library.Entry, error) {
-	entry := &library.Entry{
-		Executable:  path,
-		Description: g.Properties["name"],
-		Inports:     []library.EntryPort{},
-		Outports:    []library.EntryPort{},
+// executablePath returns a path of the component file to store in the library
+// (relative to the library file if --relative is provided)
+func executablePath(c *cli.Context, file string) (string, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	if !c.Bool("relative") {
+		return path, nil
+	}
+	return newResolver(c).Relative(path)
+}
+
+// Searches the library components
+func searchLibrary(c *cli.Context) {
+	if len(c.Args()) == 0 && c.String("in-type") == "" && c.String("out-type") == "" {
+		fmt.Printf("Incorrect Usage. You need to provide search terms or port types!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	results := library.Search(db, library.Query{
+		Terms:   c.Args(),
+		InType:  c.String("in-type"),
+		OutType: c.String("out-type"),
+	})
+	if limit := c.Int("limit"); limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	if c.Bool("json") {
+		data, err := json.MarshalIndent(results, "", "   ")
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if len(results) == 0 {
+		fmt.Println("Nothing found")
+		return
+	}
+	for _, r := range results {
+		fmt.Printf("%-30s %s\n", r.Key, strings.SplitN(r.Entry.Description, "\n", 2)[0])
+		fmt.Printf("%-30s (%s)\n", "", strings.Join(r.Matches, ", "))
+	}
+}
+
+// Generates documentation of the library components
+func docsLibrary(c *cli.Context) {
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	generator := &docs.Generator{
+		Registrar: db,
+		Resolver:  newResolver(c),
+		Format:    docs.Format(c.String("format")),
+		Title:     c.String("title"),
+		Warn:      log.WarnOutput,
+	}
+	if err = generator.Generate(c.String("out")); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Documentation of %v components written to %s\n", len(db.List()), c.String("out"))
+}
+
+// Removes a component from the library
+func removeFromLibrary(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		fmt.Printf("Incorrect Usage. You need to provide a component name as argument!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	file, db, err := editableLibrary(c)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	name := strings.ToLower(c.Args().First())
+	if err = db.Remove(name); err != nil {
+		fmt.Printf("Failed to remove \"%s\": %s\n", name, err.Error())
+		os.Exit(1)
+	}
+	if base, _ := library.SplitVersion(name); !db.Exists(base) {
+		for _, user := range componentUsers(db, base, newResolver(c)) {
+			fmt.Printf("WARNING \"%s\" is still used by \"%s\"\n", base, user)
+		}
+	}
+	if err = writeLibrary(file, db); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Removed \"%s\"\n", name)
+}
+
+// Renames a component in the library
+func renameInLibrary(c *cli.Context) {
+	if len(c.Args()) != 2 {
+		fmt.Printf("Incorrect Usage. You need to provide old and new component names as arguments!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+
+	file, db, err := editableLibrary(c)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	oldName := strings.ToLower(c.Args()[0])
+	newName := strings.ToLower(c.Args()[1])
+	if err = db.Rename(oldName, newName); err != nil {
+		fmt.Printf("Failed to rename \"%s\": %s\n", oldName, err.Error())
+		os.Exit(1)
+	}
+	if base, _ := library.SplitVersion(oldName); !db.Exists(base) {
+		for _, user := range componentUsers(db, base, newResolver(c)) {
+			fmt.Printf("WARNING \"%s\" still refers to \"%s\"\n", user, base)
+		}
+	}
+	if err = writeLibrary(file, db); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Renamed \"%s\" to \"%s\"\n", oldName, newName)
+}
+
+// Checks that all library entries are still valid: executables exist and
+// describe the same ports, subgraphs refer to registered components
+func validateLibrary(c *cli.Context) {
+	_, db, err := editableLibrary(c)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	resolver := newResolver(c)
+
+	invalid := 0
+	for _, name := range entryNames(db) {
+		problems := validateEntry(db, db.Entries[name], resolver)
+		for _, p := range problems {
+			fmt.Printf("%s: %s\n", name, p)
+		}
+		if len(problems) > 0 {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		fmt.Printf("%v of %v entries are invalid\n", invalid, len(db.Entries))
+		os.Exit(1)
+	}
+	fmt.Printf("All %v entries are valid\n", len(db.Entries))
+}
+
+// Removes entries which executables do not exist anymore
+func pruneLibrary(c *cli.Context) {
+	file, db, err := editableLibrary(c)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
+	resolver := newResolver(c)
 
-	for _, e := range g.Inports {
-		parts := strings.SplitN(e.Private, ".", 2)
-		rec, err := r.Get(g.Processes[parts[0]].Component)
+	pruned := 0
+	for _, name := range entryNames(db) {
+		if _, err := entryExecutable(db.Entries[name], resolver); err == nil {
+			continue
+		}
+		if c.Bool("dry") {
+			fmt.Printf("Would remove \"%s\" (%s)\n", name, db.Entries[name].Executable)
+		} else {
+			fmt.Printf("Removed \"%s\" (%s)\n", name, db.Entries[name].Executable)
+			db.Remove(name)
+		}
+		pruned++
+	}
+	if pruned == 0 {
+		fmt.Println("Nothing to prune")
+		return
+	}
+	if c.Bool("dry") {
+		return
+	}
+	if err = writeLibrary(file, db); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateEntry returns a list of problems found in a library entry
+func validateEntry(db *library.JSONLibrary, e library.Entry, resolver *library.Resolver) []string {
+	path, err := entryExecutable(e, resolver)
+	if err != nil {
+		return []string{fmt.Sprintf("executable %s not found", e.Executable)}
+	}
+
+	if !e.Elementary {
+		g, err := graph.ParseFile(path)
 		if err != nil {
-			return nil, fmt.Errorf("Component %s not found in library", parts[0])
+			return []string{err.Error()}
 		}
-		port, found := rec.FindInport(strings.ToLower(parts[1]))
-		if !found {
-			return nil, fmt.Errorf("Port %s not found in component %s", parts[1], parts[0])
+		problems := []string{}
+		for _, p := range processNames(g) {
+			ref, err := g.Processes[p].ComponentRef()
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("process %s: %s", p, err.Error()))
+			} else if !db.Exists(ref) {
+				problems = append(problems, fmt.Sprintf("process %s refers to missing component %s", p, ref))
+			}
 		}
-		port.Name = e.Public
-		entry.Inports = append(entry.Inports, port)
+		return problems
 	}
 
-	for _, e := range g.Outports {
-		parts := strings.SplitN(e.Private, ".", 2)
-		rec, err := r.Get(g.Processes[parts[0]].Component)
+	current, err := library.Introspect(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	problems := []string{}
+	if stale, err := e.Stale(path); err == nil && stale {
+		problems = append(problems, "executable has changed since it was added")
+	}
+	if current.Version != e.Version {
+		problems = append(problems, fmt.Sprintf("version has changed: %s (registered %s)", current.Version, e.Version))
+	}
+	problems = append(problems, comparePorts("inport", e.Inports, current.Inports)...)
+	return append(problems, comparePorts("outport", e.Outports, current.Outports)...)
+}
+
+// comparePorts describes differences between registered and actual ports
+func comparePorts(kind string, registered, actual []library.EntryPort) []string {
+	problems := []string{}
+	ports := map[string]library.EntryPort{}
+	for _, p := range actual {
+		ports[strings.ToLower(p.Name)] = p
+	}
+	for _, p := range registered {
+		name := strings.ToLower(p.Name)
+		a, ok := ports[name]
+		delete(ports, name)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s has been removed", kind, strings.ToUpper(name)))
+			continue
+		}
+		if a.Type != p.Type || a.Required != p.Required || a.Addressable != p.Addressable {
+			problems = append(problems, fmt.Sprintf("%s %s has changed: type=%s, array=%v, required=%v (registered type=%s, array=%v, required=%v)",
+				kind, strings.ToUpper(name), a.Type, a.Addressable, a.Required, p.Type, p.Addressable, p.Required))
+		}
+	}
+	added := []string{}
+	for name := range ports {
+		added = append(added, name)
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		problems = append(problems, fmt.Sprintf("%s %s has been added", kind, strings.ToUpper(name)))
+	}
+	return problems
+}
+
+// componentUsers returns names of composite entries which graphs use a given component
+func componentUsers(db *library.JSONLibrary, component string, resolver *library.Resolver) []string {
+	users := []string{}
+	for _, name := range entryNames(db) {
+		e := db.Entries[name]
+		if e.Elementary {
+			continue
+		}
+		path, err := entryExecutable(e, resolver)
 		if err != nil {
-			return nil, fmt.Errorf("Component %s not found in library", parts[0])
+			continue
+		}
+		g, err := graph.ParseFile(path)
+		if err != nil {
+			continue
 		}
-		port, found := rec.FindOutport(strings.ToLower(parts[1]))
-		if !found {
-			return nil, fmt.Errorf("Port %s not found in component %s", parts[1], parts[0])
+		for _, p := range g.Processes {
+			if ref, _ := library.SplitVersion(p.Component); ref == component {
+				users = append(users, name)
+				break
+			}
 		}
-		port.Name = e.Public
-		entry.Outports = append(entry.Outports, port)
 	}
+	return users
+}
+
+// entryExecutable returns a resolved path of the entry executable making
+// sure it exists
+func entryExecutable(e library.Entry, resolver *library.Resolver) (string, error) {
+	path, err := resolver.Resolve(e.Executable)
+	if err != nil {
+		return "", err
+	}
+	if _, err = os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
 
-	return entry, nil
+func entryNames(db *library.JSONLibrary) []string {
+	names := []string{}
+	for name := range db.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func processNames(g *graph.Description) []string {
+	names := []string{}
+	for name := range g.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// libraryFile returns a path of the JSON library file modified by the
+// library commands (the first of the libraries given with --file)
+func libraryFile(c *cli.Context) (string, error) {
+	uris := library.SplitURIs(c.GlobalString("file"))
+	if len(uris) == 0 {
+		return "", fmt.Errorf("Library is not specified")
+	}
+	path, ok := library.FilePath(uris[0])
+	if !ok {
+		return "", fmt.Errorf("Library %s is read-only", uris[0])
+	}
+	return path, nil
+}
+
+// editableLibrary reads the JSON library file modified by the library commands
+func editableLibrary(c *cli.Context) (string, *library.JSONLibrary, error) {
+	file, err := libraryFile(c)
+	if err != nil {
+		return "", nil, err
+	}
+	db, err := library.ReadJSONLibrary(file)
+	return file, db, err
+}
+
+// newResolver creates a resolver of the executables relative to the library file
+func newResolver(c *cli.Context) *library.Resolver {
+	return library.NewResolver(library.LocalFile(c.GlobalString("file")))
+}
+
+// writeLibrary saves a given library to the file
+func writeLibrary(file string, db *library.JSONLibrary) error {
+	db.Updated = time.Now()
+	result, err := db.JSON()
+	if err != nil {
+		return fmt.Errorf("Failed to generate JSON: %s", err.Error())
+	}
+	if err = ioutil.WriteFile(file, result, os.FileMode(0644)); err != nil {
+		return fmt.Errorf("Failed to save registry file: %s", err.Error())
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"time"
 
 	"github.com/codegangsta/cli"
 )
@@ -39,6 +40,54 @@ func main() {
 					Name:  "dry",
 					Usage: "dry run (parses and validates the graph, exits without executing it)",
 				},
+				cli.BoolFlag{
+					Name:  "reload",
+					Usage: "reconfigure the running network when the graph is modified (on SIGHUP)",
+				},
+				cli.DurationFlag{
+					Name:  "grace",
+					Value: 5 * time.Second,
+					Usage: "how long processes wait for their peers to reconnect when reloading is enabled",
+				},
+				cli.BoolFlag{
+					Name:  "watch",
+					Usage: "reload the graph or restart processes when graph files or component executables change",
+				},
+				cli.DurationFlag{
+					Name:  "debounce",
+					Value: 500 * time.Millisecond,
+					Usage: "how long to wait for further changes of the watched files before applying them",
+				},
+				cli.StringFlag{
+					Name:  "stale",
+					Value: "refresh",
+					Usage: "what to do with components which executables changed since added to the library: refresh, warn or fail",
+				},
+				cli.StringFlag{
+					Name:  "log-format",
+					Value: "text",
+					Usage: "format of the processes output: text or json (one JSON object per line)",
+				},
+				cli.StringSliceFlag{
+					Name:  "log-level",
+					Value: &cli.StringSlice{},
+					Usage: "minimal level of the output (debug, info, warn or error), use Process=level to set it for a single process",
+				},
+				cli.StringFlag{
+					Name:  "log-dir",
+					Value: "",
+					Usage: "directory to additionally write output of every process to (as <process>.log)",
+				},
+				cli.IntFlag{
+					Name:  "log-max-size",
+					Value: 10,
+					Usage: "size in megabytes after which a process log file is rotated",
+				},
+				cli.IntFlag{
+					Name:  "log-max-files",
+					Value: 5,
+					Usage: "number of rotated log files to keep per process",
+				},
 			},
 		},
 		{
@@ -59,6 +108,10 @@ func main() {
 							Name:  "force",
 							Usage: "enforces updating a component entry in the library if it already exists",
 						},
+						cli.BoolFlag{
+							Name:  "relative",
+							Usage: "stores component paths relative to the library file (if located under its directory)",
+						},
 					},
 				},
 				{
@@ -71,27 +124,207 @@ func main() {
 					Usage:  "prints details for a given component",
 					Action: infoFromLibrary,
 				},
+				{
+					Name:   "search",
+					Usage:  "searches components by names, descriptions and ports (e.g. search file --in-type json --out-type string)",
+					Action: searchLibrary,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "in-type",
+							Value: "",
+							Usage: "only components having an inport of this type",
+						},
+						cli.StringFlag{
+							Name:  "out-type",
+							Value: "",
+							Usage: "only components having an outport of this type",
+						},
+						cli.IntFlag{
+							Name:  "limit",
+							Value: 20,
+							Usage: "maximum number of results to show (0 shows all)",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "prints results in JSON",
+						},
+					},
+				},
+				{
+					Name:   "docs",
+					Usage:  "generates documentation pages for all components",
+					Action: docsLibrary,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format",
+							Value: "markdown",
+							Usage: "format of the pages: markdown or html",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Value: "docs",
+							Usage: "directory to write the pages to",
+						},
+						cli.StringFlag{
+							Name:  "title",
+							Value: "Components Library",
+							Usage: "title of the index page",
+						},
+					},
+				},
+				{
+					Name:   "remove",
+					Usage:  "removes a given component from the library",
+					Action: removeFromLibrary,
+				},
+				{
+					Name:   "rename",
+					Usage:  "renames a component in the library (old new)",
+					Action: renameInLibrary,
+				},
+				{
+					Name:   "validate",
+					Usage:  "checks that executables of all components exist and describe the registered ports (exits with non-zero status if not)",
+					Action: validateLibrary,
+				},
+				{
+					Name:   "prune",
+					Usage:  "removes components which executables do not exist anymore",
+					Action: pruneLibrary,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "dry",
+							Usage: "only prints components which would be removed",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:  "graph",
+			Usage: "Tools for working with graph definitions",
+			Subcommands: []cli.Command{
+				{
+					Name:   "render",
+					Usage:  "renders a given graph as a diagram (Graphviz DOT or Mermaid)",
+					Action: renderGraph,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format",
+							Value: "dot",
+							Usage: "diagram format: dot or mermaid",
+						},
+						cli.BoolFlag{
+							Name:  "subgraphs",
+							Usage: "expands composite components into clusters (requires the library)",
+						},
+					},
+				},
+				{
+					Name:   "lint",
+					Usage:  "checks a given graph for suspicious patterns (exits with non-zero status on errors)",
+					Action: lintGraph,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "prints found issues in JSON",
+						},
+						cli.BoolFlag{
+							Name:  "strict",
+							Usage: "exits with non-zero status on warnings as well",
+						},
+					},
+				},
+				{
+					Name:   "diff",
+					Usage:  "prints a structural difference between two graphs (exits with status 1 if they differ)",
+					Action: diffGraphs,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "prints the difference in JSON",
+						},
+					},
+				},
+				{
+					Name:   "merge",
+					Usage:  "merges two graphs derived from a common base (base ours theirs), conflicting changes are resolved in favour of ours",
+					Action: mergeGraphs,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "output, o",
+							Value: "",
+							Usage: "file to save the merged graph (JSON) to instead of printing it",
+						},
+					},
+				},
 			},
 		},
-		/*
-			{
-				Name:  "serve",
-				Usage: "Start a runtime server for executing submitted graphs",
-				Flags: []cli.Flag{
-					cli.StringFlag{
-						Name:  "addr",
-						Value: "0.0.0.0:7878",
-						Usage: "binding address for the server",
+		{
+			Name:   "serve",
+			Usage:  "Serves the components library read-only over HTTP (use http://addr as library for other runtimes)",
+			Action: serve,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Value: "0.0.0.0:7878",
+					Usage: "binding address for the server",
+				},
+			},
+		},
+		{
+			Name:  "new",
+			Usage: "Generates new components and graphs",
+			Subcommands: []cli.Command{
+				{
+					Name:   "component",
+					Usage:  "generates a Go component directory (e.g. new component myorg/filter --in IN:json --out OUT:string)",
+					Action: newComponent,
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  "in",
+							Value: &cli.StringSlice{},
+							Usage: "inport given as NAME[:type] (repeatable)",
+						},
+						cli.StringSliceFlag{
+							Name:  "out",
+							Value: &cli.StringSlice{},
+							Usage: "outport given as NAME[:type] (repeatable)",
+						},
+						cli.StringFlag{
+							Name:  "description",
+							Value: "",
+							Usage: "description of the component",
+						},
+						cli.StringFlag{
+							Name:  "dir",
+							Value: ".",
+							Usage: "directory to create the component directory in",
+						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "overwrites files in an existing directory",
+						},
 					},
-					cli.StringFlag{
-						Name:  "static",
-						Value: "static",
-						Usage: "root directory with static resources (will be mounted as /static/)",
+				},
+				{
+					Name:   "graph",
+					Usage:  "generates a starter .fbp graph chaining given library components (e.g. new graph app.fbp core/readfile core/console)",
+					Action: newGraph,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "name",
+							Value: "",
+							Usage: "title of the graph (defaults to the file name)",
+						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "overwrites an existing file",
+						},
 					},
 				},
-				Action: serve,
 			},
-		*/
+		},
 	}
 
 	app.Run(os.Args)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cascades-fbp/cascades/library"
+	"github.com/cascades-fbp/cascades/scaffold"
+	"github.com/codegangsta/cli"
+)
+
+// Generates a directory of a new elementary component
+func newComponent(c *cli.Context) {
+	if len(c.Args()) != 1 {
+		fmt.Printf("Incorrect Usage. You need to provide a name of the component!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+	name := c.Args().First()
+
+	component := &scaffold.Component{
+		Name:        name,
+		Description: c.String("description"),
+		Inports:     []library.EntryPort{},
+		Outports:    []library.EntryPort{},
+	}
+	if component.Description == "" {
+		component.Description = fmt.Sprintf("TODO: describe %s component", name)
+	}
+	for _, spec := range c.StringSlice("in") {
+		p, err := scaffold.ParsePort(spec, true)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		component.Inports = append(component.Inports, p)
+	}
+	for _, spec := range c.StringSlice("out") {
+		p, err := scaffold.ParsePort(spec, false)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		component.Outports = append(component.Outports, p)
+	}
+
+	dir := filepath.Join(c.String("dir"), filepath.FromSlash(name))
+	if files, _ := ioutil.ReadDir(dir); len(files) > 0 && !c.Bool("force") {
+		fmt.Printf("Directory %s is not empty and --force is not provided\n", dir)
+		os.Exit(1)
+	}
+	if err := component.Generate(dir); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Generated component %s in %s\n", name, dir)
+}
+
+// Generates a starter graph using given library components
+func newGraph(c *cli.Context) {
+	if len(c.Args()) < 2 {
+		fmt.Printf("Incorrect Usage. You need to provide a graph file and components to use!\n\n")
+		cli.ShowAppHelp(c)
+		return
+	}
+	file := c.Args().First()
+	if !strings.HasSuffix(file, ".fbp") {
+		fmt.Println("Graph file should have .fbp extension")
+		os.Exit(1)
+	}
+	if _, err := os.Stat(file); err == nil && !c.Bool("force") {
+		fmt.Printf("File %s already exists and --force is not provided\n", file)
+		os.Exit(1)
+	}
+
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	g := &scaffold.Graph{
+		Name:       c.String("name"),
+		Components: c.Args().Tail(),
+		Registrar:  db,
+	}
+	if g.Name == "" {
+		g.Name = strings.TrimSuffix(filepath.Base(file), ".fbp")
+	}
+	var buf bytes.Buffer
+	if err = g.WriteFBP(&buf); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(file, buf.Bytes(), os.FileMode(0644)); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("Generated graph %s\n", file)
+}
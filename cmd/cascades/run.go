@@ -1,13 +1,14 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/cascades-fbp/cascades/library"
+	"github.com/cascades-fbp/cascades/log"
 	"github.com/cascades-fbp/cascades/runtime"
 	"github.com/codegangsta/cli"
 	zmq "github.com/pebbe/zmq4"
@@ -20,22 +21,48 @@ func run(c *cli.Context) {
 		return
 	}
 
-	// read components library file if exists
-	data, err := ioutil.ReadFile(c.GlobalString("file"))
+	format, err := log.ParseFormat(c.String("log-format"))
 	if err != nil {
-		fmt.Printf("Failed to read catalogue file: %s\n", err.Error())
+		fmt.Println(err.Error())
 		return
 	}
-	var db library.JSONLibrary
-	err = json.Unmarshal(data, &db)
+	log.DefaultFactory.Format = format
+	log.DefaultFactory.Dir = c.String("log-dir")
+	log.DefaultFactory.MaxSize = int64(c.Int("log-max-size")) * 1024 * 1024
+	log.DefaultFactory.MaxBackups = c.Int("log-max-files")
+
+	// open components library
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	// check components for executables changed since added to the library
+	policy, err := library.ParseStalePolicy(c.String("stale"))
 	if err != nil {
-		fmt.Printf("Failed to parse catalogue file: %s\n", err.Error())
+		fmt.Println(err.Error())
 		return
 	}
+	checked := library.NewChecked(db, newResolver(c), policy)
+	checked.Notify = log.WarnOutput
 
 	// create runtime for a graph, validate and execute it
-	scheduler := runtime.NewRuntime(db, uint(c.Int("port")))
+	scheduler := runtime.NewRuntime(checked, uint(c.Int("port")))
 	scheduler.Debug = c.GlobalBool("debug")
+	if scheduler.Debug {
+		scheduler.LogLevel = log.LevelDebug
+	}
+	err = parseLogLevels(scheduler, c.StringSlice("log-level"))
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	log.DefaultFactory.Level = scheduler.LogLevel
+	scheduler.Resolver = newResolver(c)
+	if c.Bool("reload") || c.Bool("watch") {
+		scheduler.ReconnectGrace = c.Duration("grace")
+	}
 	err = scheduler.LoadGraph(c.Args().First())
 	if err != nil {
 		fmt.Printf("Failed to load/flatten graph: %s\n", err.Error())
@@ -49,19 +76,76 @@ func run(c *cli.Context) {
 	// Start the network
 	go scheduler.Start(c.Bool("dry"))
 
+	// All reloads go through a single goroutine: the watcher if enabled
+	reloadCh := make(chan bool, 1)
+	watching := false
+	if c.Bool("watch") && !c.Bool("dry") {
+		err = watchNetwork(scheduler, c.Args().First(), c.Duration("debounce"), reloadCh)
+		if err != nil {
+			log.ErrorOutput("Failed to watch graph files: " + err.Error())
+		} else {
+			watching = true
+		}
+	}
+	if !watching {
+		go func() {
+			for range reloadCh {
+				reloadGraph(scheduler, c.Args().First())
+			}
+		}()
+	}
+
 	// Shutdown ZMQ upon shutdown
 	defer zmq.Term()
 
-	// Ctrl+C handling
+	// Ctrl+C handling (and SIGHUP for reloading the graph if enabled)
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt)
+	if c.Bool("reload") {
+		signal.Notify(ch, syscall.SIGHUP)
+	}
 	for {
 		select {
-		case <-ch:
+		case sig := <-ch:
+			if sig == syscall.SIGHUP {
+				// a reload requested while another one is pending is merged with it
+				select {
+				case reloadCh <- true:
+				default:
+				}
+				continue
+			}
 			go scheduler.Shutdown()
 		case <-scheduler.Done:
+			log.DefaultFactory.Close()
 			fmt.Println("Stopped")
 			os.Exit(0)
 		}
 	}
 }
+
+// reloadGraph applies the modified graph to the running network
+func reloadGraph(scheduler *runtime.Runtime, graphfile string) {
+	log.SystemOutput("Reloading graph " + graphfile)
+	if err := scheduler.Reload(graphfile); err != nil {
+		log.ErrorOutput("Failed to reload graph: " + err.Error())
+	}
+}
+
+// parseLogLevels sets the default and per-process log levels given either
+// as a plain level or as Process=level
+func parseLogLevels(scheduler *runtime.Runtime, values []string) error {
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		level, err := log.ParseLevel(parts[len(parts)-1])
+		if err != nil {
+			return err
+		}
+		if len(parts) == 1 {
+			scheduler.LogLevel = level
+		} else {
+			scheduler.LogLevels[parts[0]] = level
+		}
+	}
+	return nil
+}
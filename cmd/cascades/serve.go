@@ -2,25 +2,24 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
-	"os/signal"
 
-	"github.com/cascades-fbp/cascades/server"
+	"github.com/cascades-fbp/cascades/library"
 	"github.com/codegangsta/cli"
 )
 
+// Serves the components library over HTTP
 func serve(c *cli.Context) {
-	restfulAPI := server.NewRESTfulAPI()
-	go restfulAPI.Start(c.String("addr"), c.String("static"))
-
-	// Ctrl+C handling
-	handler := make(chan os.Signal, 1)
-	signal.Notify(handler, os.Interrupt)
-	for sig := range handler {
-		if sig == os.Interrupt {
-			fmt.Println("Caught interrupt signal...")
-			break
-		}
+	db, err := library.Open(c.GlobalString("file"))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
+	fmt.Printf("Serving library %s on http://%s/entries\n", c.GlobalString("file"), c.String("addr"))
+	if err = http.ListenAndServe(c.String("addr"), library.Handler(db)); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
 }
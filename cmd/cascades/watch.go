@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cascades-fbp/cascades/log"
+	"github.com/cascades-fbp/cascades/runtime"
+	"github.com/howeyc/fsnotify"
+)
+
+// networkWatcher watches the graph files and component executables of
+// a running network and reloads the affected parts when they change
+type networkWatcher struct {
+	scheduler   *runtime.Runtime
+	graphfile   string
+	debounce    time.Duration
+	watcher     *fsnotify.Watcher
+	dirs        map[string]bool
+	graphs      map[string]bool
+	executables map[string][]string
+	// reload receives requests to reload the graph (e.g. on SIGHUP)
+	reload <-chan bool
+}
+
+// watchNetwork starts watching files of a given network in background.
+// Reload requests received on a given channel are handled by the watcher too
+func watchNetwork(scheduler *runtime.Runtime, graphfile string, debounce time.Duration, reload <-chan bool) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	w := &networkWatcher{
+		scheduler: scheduler,
+		graphfile: graphfile,
+		debounce:  debounce,
+		watcher:   watcher,
+		dirs:      map[string]bool{},
+		reload:    reload,
+	}
+	if err = w.update(); err != nil {
+		watcher.Close()
+		return err
+	}
+	go w.loop()
+	return nil
+}
+
+// update refreshes the list of watched files. Directories are watched
+// instead of the files themselves, so files replaced by editors and build
+// tools (removed and created again) are still tracked
+func (w *networkWatcher) update() error {
+	executables, err := w.scheduler.Executables()
+	if err != nil {
+		return err
+	}
+	w.graphs = map[string]bool{}
+	for _, path := range w.scheduler.GraphFiles() {
+		w.graphs[filepath.Clean(path)] = true
+	}
+	w.executables = map[string][]string{}
+	for path, names := range executables {
+		w.executables[filepath.Clean(path)] = names
+	}
+
+	dirs := map[string]bool{}
+	for path := range w.graphs {
+		dirs[filepath.Dir(path)] = true
+	}
+	for path := range w.executables {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err = w.watcher.Watch(dir); err != nil {
+			return fmt.Errorf("Failed to watch %s: %s", dir, err.Error())
+		}
+	}
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.RemoveWatch(dir)
+		}
+	}
+	w.dirs = dirs
+	return nil
+}
+
+// loop collects changes of the watched files and applies them when no
+// more changes happen during the debounce interval
+func (w *networkWatcher) loop() {
+	pending := map[string]bool{}
+	var timer <-chan time.Time
+	for {
+		select {
+		case ev := <-w.watcher.Event:
+			path := filepath.Clean(ev.Name)
+			if !w.graphs[path] && w.executables[path] == nil {
+				continue
+			}
+			pending[path] = true
+			timer = time.After(w.debounce)
+		case err := <-w.watcher.Error:
+			log.ErrorOutput("Watcher error: " + err.Error())
+		case <-w.reload:
+			path, err := filepath.Abs(w.graphfile)
+			if err != nil {
+				log.ErrorOutput("Failed to reload graph: " + err.Error())
+				continue
+			}
+			pending[path] = true
+			w.apply(pending)
+			pending = map[string]bool{}
+			timer = nil
+		case <-timer:
+			w.apply(pending)
+			pending = map[string]bool{}
+			timer = nil
+		}
+	}
+}
+
+// apply reloads the graph if any of the graph files changed and restarts
+// processes which executables changed
+func (w *networkWatcher) apply(changed map[string]bool) {
+	paths := []string{}
+	for path := range changed {
+		if _, err := os.Stat(path); err != nil {
+			log.WarnOutput(fmt.Sprintf("%s has been removed, waiting for it to appear again", path))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	reload := false
+	for _, path := range paths {
+		if w.graphs[path] {
+			reload = true
+			break
+		}
+	}
+	if reload {
+		log.SystemOutput("Graph has changed, reloading " + w.graphfile)
+		if err := w.scheduler.Reload(w.graphfile); err != nil {
+			log.ErrorOutput("Failed to reload graph: " + err.Error())
+		}
+		// processes of the reloaded graph may use other executables now
+		if err := w.update(); err != nil {
+			log.ErrorOutput("Failed to update watched files: " + err.Error())
+			return
+		}
+	}
+
+	restart := []string{}
+	for _, path := range paths {
+		restart = append(restart, w.executables[path]...)
+	}
+	if len(restart) > 0 {
+		log.SystemOutput("Executables have changed, restarting " + strings.Join(restart, ", "))
+		if err := w.scheduler.Restart(restart...); err != nil {
+			log.ErrorOutput("Failed to restart processes: " + err.Error())
+		}
+	}
+
+	if !reload {
+		if err := w.update(); err != nil {
+			log.ErrorOutput("Failed to update watched files: " + err.Error())
+		}
+	}
+}
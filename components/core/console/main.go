@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
@@ -37,12 +36,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
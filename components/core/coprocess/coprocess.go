@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// outputGrace is how long the output is read after the program exits
+const outputGrace = time.Second
+
+// coprocess keeps an external program running, restarting it when it exits
+type coprocess struct {
+	command string
+	opts    *options
+	// output receives every frame of the program's standard output
+	output func([]byte)
+	// errors receives lines of the program's standard error and notices
+	errors func([]byte)
+
+	mutex   sync.Mutex
+	ready   *sync.Cond
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stopped bool
+	failed  error
+	done    chan bool
+}
+
+func newCoprocess(command string, opts *options, output, errors func([]byte)) *coprocess {
+	p := &coprocess{
+		command: command,
+		opts:    opts,
+		output:  output,
+		errors:  errors,
+		done:    make(chan bool),
+	}
+	p.ready = sync.NewCond(&p.mutex)
+	return p
+}
+
+// Start starts the program and supervises it in background
+func (p *coprocess) Start() {
+	go p.supervise()
+}
+
+// Write sends a frame to the standard input of the program waiting for it
+// to be (re)started if needed
+func (p *coprocess) Write(data []byte) error {
+	p.mutex.Lock()
+	for p.stdin == nil && !p.stopped && p.failed == nil {
+		p.ready.Wait()
+	}
+	stdin, stopped, failed := p.stdin, p.stopped, p.failed
+	p.mutex.Unlock()
+	if failed != nil {
+		return failed
+	}
+	if stopped {
+		return fmt.Errorf("Program is stopped")
+	}
+	var frame []byte
+	if p.opts.Framing == framingLength {
+		frame = make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(frame, uint32(len(data)))
+		frame = append(frame, data...)
+	} else {
+		frame = append(append(frame, data...), '\n')
+	}
+	_, err := stdin.Write(frame)
+	return err
+}
+
+// Stop closes the standard input of the program and waits for it to exit.
+// The program is terminated if it is still running after a given timeout
+// and killed if it ignores termination
+func (p *coprocess) Stop(timeout time.Duration) {
+	p.mutex.Lock()
+	p.stopped = true
+	if p.stdin != nil {
+		p.stdin.Close()
+	}
+	p.ready.Broadcast()
+	p.mutex.Unlock()
+
+	signals := map[syscall.Signal]string{syscall.SIGTERM: "SIGTERM", syscall.SIGKILL: "SIGKILL"}
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL} {
+		select {
+		case <-p.done:
+			return
+		case <-time.After(timeout):
+		}
+		p.mutex.Lock()
+		if p.cmd != nil {
+			log.Printf("Program is still running, sending %s", signals[sig])
+			signalCommand(p.cmd, sig)
+		}
+		p.mutex.Unlock()
+	}
+	<-p.done
+}
+
+// supervise runs the program until stopped, restarting it with a backoff
+func (p *coprocess) supervise() {
+	defer close(p.done)
+	restarts := 0
+	for {
+		err := p.run()
+
+		p.mutex.Lock()
+		p.cmd = nil
+		p.stdin = nil
+		stopped := p.stopped
+		p.mutex.Unlock()
+		if stopped {
+			return
+		}
+
+		msg := "Program exited"
+		if err != nil {
+			msg = fmt.Sprintf("Program exited: %s", err.Error())
+		}
+		restarts++
+		if p.opts.MaxRestarts > 0 && restarts > p.opts.MaxRestarts {
+			p.fail(fmt.Errorf("%s, giving up after %v restarts", msg, p.opts.MaxRestarts))
+			return
+		}
+		log.Printf("%s, restarting in %s", msg, p.opts.backoff)
+		p.errors([]byte(fmt.Sprintf("%s, restarting in %s", msg, p.opts.backoff)))
+		time.Sleep(p.opts.backoff)
+
+		p.mutex.Lock()
+		stopped = p.stopped
+		p.mutex.Unlock()
+		if stopped {
+			return
+		}
+	}
+}
+
+// run starts the program and blocks until it exits
+func (p *coprocess) run() error {
+	cmd := newCommand(p.command)
+	cmd.Dir = p.opts.Dir
+	cmd.Env = p.opts.Environ()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// output pipes are created explicitly (not with StdoutPipe) so that
+	// the program can be waited for before its output is read completely
+	stdout, stdoutW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderr, stderrW, err := os.Pipe()
+	if err != nil {
+		stdout.Close()
+		stdoutW.Close()
+		return err
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	err = cmd.Start()
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
+		return err
+	}
+	log.Printf("Started program (pid %v): %s", cmd.Process.Pid, p.command)
+
+	p.mutex.Lock()
+	if p.stopped {
+		stdin.Close()
+	}
+	p.cmd = cmd
+	p.stdin = stdin
+	p.ready.Broadcast()
+	p.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if p.opts.Framing != framingLength {
+			readLines(stdout, p.output)
+			return
+		}
+		if err := readFrames(stdout, p.opts.MaxFrame, p.output); err != nil {
+			log.Println("ERROR:", err.Error())
+			p.errors([]byte(err.Error()))
+			// the rest of the output can not be framed anymore
+			io.Copy(ioutil.Discard, stdout)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		readLines(stderr, p.errors)
+	}()
+	err = cmd.Wait()
+
+	// children of the program may keep the output open: the rest of the
+	// output is read for a while and the pipes are closed then
+	read := make(chan bool)
+	go func() {
+		wg.Wait()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(outputGrace):
+		log.Println("Output of the program is still open, closing it")
+	}
+	stdout.Close()
+	stderr.Close()
+	<-read
+	return err
+}
+
+// fail marks the program as failed for good
+func (p *coprocess) fail(err error) {
+	log.Println("ERROR:", err.Error())
+	p.errors([]byte(err.Error()))
+	p.mutex.Lock()
+	p.failed = err
+	p.ready.Broadcast()
+	p.mutex.Unlock()
+}
+
+// readLines passes every line read from a reader to a given callback
+// (without the line break). Lines are not limited in length
+func readLines(r io.Reader, line func([]byte)) {
+	reader := bufio.NewReader(r)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			data = bytes.TrimSuffix(data, []byte("\n"))
+			line(bytes.TrimSuffix(data, []byte("\r")))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readFrames passes every length-prefixed frame read from a reader to a
+// given callback. Frames longer than a given size are refused
+func readFrames(r io.Reader, max int, frame func([]byte)) error {
+	reader := bufio.NewReader(r)
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil
+		}
+		size := binary.BigEndian.Uint32(header)
+		if uint64(size) > uint64(max) {
+			return fmt.Errorf("Frame of %v bytes exceeds max_frame (%v bytes), discarding the rest of the output", size, max)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil
+		}
+		frame(data)
+	}
+}
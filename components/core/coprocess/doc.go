@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/cascades-fbp/cascades/library"
+)
+
+var registryEntry = &library.Entry{
+	Description: `Starts a given external program once and keeps it running: every IP received on IN port is written to its standard input
+and every line (or frame) of its standard output is sent as an IP to OUT port. The program is restarted if it exits.
+Framing (see OPTIONS): lines (default, an IP per line) or length (4-byte big-endian length prefix followed by the data)`,
+	Elementary: true,
+	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"framing\": \"length\", \"dir\": \"/tmp\", \"env\": {\"KEY\": \"value\"}, \"backoff\": \"1s\", \"max_restarts\": 5, \"max_frame\": 16777216}",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "CMD",
+			Type:        "string",
+			Description: "Port for configuring the program to run (executed in a shell)",
+			Required:    true,
+		},
+		library.EntryPort{
+			Name:        "IN",
+			Type:        "all",
+			Description: "Input port for IPs written to the standard input of the program",
+			Required:    true,
+		},
+	},
+	Outports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OUT",
+			Type:        "string",
+			Description: "Output port for lines (or frames) of the standard output of the program",
+			Required:    true,
+		},
+		library.EntryPort{
+			Name:        "ERR",
+			Type:        "string",
+			Description: "Output port for lines of the standard error of the program and restart notices",
+			Required:    false,
+		},
+	},
+}
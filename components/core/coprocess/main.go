@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+	"github.com/cascades-fbp/cascades/runtime"
+	zmq "github.com/pebbe/zmq4"
+)
+
+var (
+	// Flags
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	cmdEndpoint     = flag.String("port.cmd", "", "Component's command port endpoint")
+	inputEndpoint   = flag.String("port.in", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.out", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
+
+	// Internal
+	optionsPort, cmdPort, inPort, outPort, errPort *zmq.Socket
+	inCh, outCh, errCh                             chan bool
+	outData, errData                               chan []byte
+	exitCh                                         chan os.Signal
+	err                                            error
+
+	// proc is stopped by main on termination, so it is guarded by procMx
+	proc     *coprocess
+	stopping bool
+	procMx   sync.Mutex
+)
+
+func main() {
+	flag.Parse()
+
+	if *jsonFlag {
+		doc, _ := registryEntry.JSON()
+		fmt.Println(string(doc))
+		os.Exit(0)
+	}
+
+	utils.SetupLogging(*debug)
+
+	validateArgs()
+
+	// Communication channels
+	inCh = make(chan bool)
+	outCh = make(chan bool)
+	errCh = make(chan bool)
+	outData = make(chan []byte)
+	errData = make(chan []byte)
+	exitCh = make(chan os.Signal, 1)
+
+	// Start the communication & processing logic
+	go mainLoop()
+
+	// Wait for the end...
+	signal.Notify(exitCh, os.Interrupt, syscall.SIGTERM)
+	<-exitCh
+
+	// Let the program finish its work (it is not started anymore if the
+	// command has not been received yet)
+	procMx.Lock()
+	stopping = true
+	p := proc
+	procMx.Unlock()
+	if p != nil {
+		p.Stop(5 * time.Second)
+	}
+
+	log.Println("Done")
+}
+
+// mainLoop initiates all ports and handles the traffic
+func mainLoop() {
+	openPorts()
+	defer closePorts()
+
+	ports := 2
+	if errPort != nil {
+		ports++
+	}
+
+	waitCh := make(chan bool)
+	inExitCh := make(chan bool, 1)
+	go func(num int) {
+		total := 0
+		for {
+			select {
+			case v := <-inCh:
+				if v {
+					total++
+				} else {
+					// non-blocking: the port may disconnect several times
+					select {
+					case inExitCh <- true:
+					default:
+					}
+				}
+			case v := <-outCh:
+				if !v {
+					log.Println("OUT port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+			case v := <-errCh:
+				if !v {
+					log.Println("ERR port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+			}
+			if total >= num && waitCh != nil {
+				waitCh <- true
+			}
+		}
+	}(ports)
+
+	log.Println("Waiting for port connections to establish... ")
+	select {
+	case <-waitCh:
+		log.Println("Ports connected")
+		waitCh = nil
+	case <-time.Tick(30 * time.Second):
+		log.Println("Timeout: port connections were not established within provided interval")
+		exitCh <- syscall.SIGTERM
+		return
+	}
+
+	// Output ports are used by a single goroutine each
+	var senders sync.WaitGroup
+	senders.Add(2)
+	go func() {
+		defer senders.Done()
+		for data := range outData {
+			outPort.SendMessage(runtime.NewPacket(data))
+		}
+	}()
+	go func() {
+		defer senders.Done()
+		for data := range errData {
+			if errPort != nil {
+				errPort.SendMessage(runtime.NewPacket(data))
+			}
+		}
+	}()
+
+	opts := defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				errData <- []byte(err.Error())
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
+	log.Println("Waiting for command...")
+	command := ""
+	for command == "" {
+		ip, err := cmdPort.RecvMessageBytes(0)
+		if err != nil {
+			continue
+		}
+		if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+			log.Println("Invalid IP:", ip)
+			continue
+		}
+		command = string(ip[1])
+	}
+	cmdPort.Close()
+
+	p := newCoprocess(command, opts, func(data []byte) {
+		outData <- data
+	}, func(data []byte) {
+		log.Println("STDERR:", string(data))
+		errData <- data
+	})
+	procMx.Lock()
+	if stopping {
+		procMx.Unlock()
+		return
+	}
+	proc = p
+	proc.Start()
+	procMx.Unlock()
+
+	log.Println("Started...")
+	poller := zmq.NewPoller()
+	poller.Add(inPort, zmq.POLLIN)
+	for {
+		results, err := poller.Poll(time.Second)
+		if err != nil || len(results) == 0 {
+			select {
+			case <-inExitCh:
+				// let the program process the rest of input and send its output
+				log.Println("IN port is closed. Stopping the program")
+				p.Stop(5 * time.Second)
+				close(outData)
+				close(errData)
+				senders.Wait()
+				exitCh <- syscall.SIGTERM
+				return
+			default:
+				// IN port is still open
+			}
+			continue
+		}
+		ip, err := inPort.RecvMessageBytes(0)
+		if err != nil || !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+			continue
+		}
+		if err = p.Write(ip[1]); err != nil {
+			log.Println("ERROR: Failed to write to the program:", err.Error())
+			errData <- []byte(err.Error())
+		}
+	}
+}
+
+// validateArgs checks all required flags
+func validateArgs() {
+	if *cmdEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *inputEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *outputEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+// openPorts create ZMQ sockets and start socket monitoring loops
+func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("coprocess.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
+	cmdPort, err = utils.CreateInputPort("coprocess.cmd", *cmdEndpoint, nil)
+	utils.AssertError(err)
+
+	inPort, err = utils.CreateInputPort("coprocess.in", *inputEndpoint, inCh)
+	utils.AssertError(err)
+
+	outPort, err = utils.CreateOutputPort("coprocess.out", *outputEndpoint, outCh)
+	utils.AssertError(err)
+
+	if *errorEndpoint != "" {
+		errPort, err = utils.CreateOutputPort("coprocess.err", *errorEndpoint, errCh)
+		utils.AssertError(err)
+	}
+}
+
+// closePorts closes all active ports and terminates ZMQ context
+func closePorts() {
+	log.Println("Closing ports...")
+	inPort.Close()
+	outPort.Close()
+	if errPort != nil {
+		errPort.Close()
+	}
+	zmq.Term()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Framing of the data written to and read from the program
+const (
+	// framingLines separates the IPs by newlines
+	framingLines = "lines"
+	// framingLength prefixes every IP with its length (4 bytes, big-endian)
+	framingLength = "length"
+)
+
+// defaultMaxFrame is the longest frame read from the program by default
+const defaultMaxFrame = 16 * 1024 * 1024
+
+type options struct {
+	Dir         string            `json:"dir"`
+	Env         map[string]string `json:"env"`
+	Framing     string            `json:"framing"`
+	Backoff     string            `json:"backoff"`
+	MaxRestarts int               `json:"max_restarts"`
+	MaxFrame    int               `json:"max_frame"`
+
+	backoff time.Duration
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{Framing: framingLines, MaxFrame: defaultMaxFrame, backoff: time.Second}
+}
+
+func (o *options) Validate() error {
+	switch o.Framing {
+	case "":
+		o.Framing = framingLines
+	case framingLines, framingLength:
+	default:
+		return fmt.Errorf("Unknown framing %s (should be lines or length)", o.Framing)
+	}
+	o.backoff = time.Second
+	if o.Backoff != "" {
+		d, err := time.ParseDuration(o.Backoff)
+		if err != nil {
+			return fmt.Errorf("Invalid backoff %s: %s", o.Backoff, err.Error())
+		}
+		o.backoff = d
+	}
+	if o.MaxRestarts < 0 {
+		o.MaxRestarts = 0
+	}
+	if o.MaxFrame <= 0 {
+		o.MaxFrame = defaultMaxFrame
+	}
+	if o.Dir != "" {
+		info, err := os.Stat(o.Dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("Working directory %s is not a directory", o.Dir)
+		}
+	}
+	return nil
+}
+
+// Environ returns the environment of the program: the one of the component
+// with the variables from options added
+func (o *options) Environ() []string {
+	env := os.Environ()
+	for k, v := range o.Env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
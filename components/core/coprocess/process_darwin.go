@@ -0,0 +1,19 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// newCommand creates a shell command running in its own process group
+// (to stop the program with all its children)
+func newCommand(command string) *exec.Cmd {
+	cmd := exec.Command("/bin/bash", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// signalCommand sends a signal to the process group of a started command
+func signalCommand(cmd *exec.Cmd, sig syscall.Signal) {
+	syscall.Kill(-cmd.Process.Pid, sig)
+}
@@ -0,0 +1,16 @@
+package main
+
+/*
+import (
+	"os/exec"
+	"syscall"
+)
+
+func newCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+func signalCommand(cmd *exec.Cmd, sig syscall.Signal) {
+	cmd.Process.Kill()
+}
+*/
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
@@ -43,12 +42,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
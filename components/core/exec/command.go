@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// result describes a finished command
+type result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Err      error
+}
+
+// execute runs a command with given options writing stdin (if not nil) to it.
+// In lines mode every line of stdout is passed to a given callback as soon as
+// it is printed, otherwise the whole stdout is collected in the result
+func execute(command string, stdin []byte, opts *options, line func([]byte)) *result {
+	res := &result{ExitCode: -1}
+	cmd := newCommand(command)
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Environ()
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if err = cmd.Start(); err != nil {
+		res.Err = err
+		return res
+	}
+
+	var timer *time.Timer
+	if opts.timeout > 0 {
+		timer = time.AfterFunc(opts.timeout, func() {
+			killCommand(cmd)
+		})
+	}
+
+	if opts.Output == outputLines {
+		readLines(stdout, line)
+	} else {
+		res.Stdout, _ = ioutil.ReadAll(stdout)
+	}
+
+	err = cmd.Wait()
+	res.Stderr = stderr.Bytes()
+	// the timer has already fired if it cannot be stopped
+	switch {
+	case timer != nil && !timer.Stop():
+		res.Err = fmt.Errorf("Command timed out after %s", opts.timeout)
+	case err != nil:
+		res.Err = err
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				res.ExitCode = status.ExitStatus()
+			}
+		}
+	default:
+		res.ExitCode = 0
+	}
+	return res
+}
+
+// readLines passes every line read from a reader to a given callback
+// (without the line break). Lines are not limited in length
+func readLines(r io.Reader, line func([]byte)) {
+	reader := bufio.NewReader(r)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			data = bytes.TrimSuffix(data, []byte("\n"))
+			line(bytes.TrimSuffix(data, []byte("\r")))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -5,27 +5,48 @@ import (
 )
 
 var registryEntry = &library.Entry{
-	Description: "Execute a given command",
-	Elementary:  true,
+	Description: `Executes a given command in a shell. Without IN port connected every IP received on CMD port is executed.
+When IN port is connected, CMD sets the command and every IP received on IN is written to its standard input.
+Output modes (see OPTIONS): joined (default, the whole output without newlines), whole (the output as is) and lines (every line as
+an IP within a substream of each execution)`,
+	Elementary: true,
 	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"dir\": \"/tmp\", \"env\": {\"KEY\": \"value\"}, \"timeout\": \"30s\", \"output\": \"lines\"}",
+			Required:    false,
+		},
 		library.EntryPort{
 			Name:        "CMD",
 			Type:        "string",
 			Description: "Port for configuring a command to execute",
 			Required:    true,
 		},
+		library.EntryPort{
+			Name:        "IN",
+			Type:        "all",
+			Description: "Input port for IPs written to the standard input of the command",
+			Required:    false,
+		},
 	},
 	Outports: []library.EntryPort{
 		library.EntryPort{
 			Name:        "OUT",
 			Type:        "string",
-			Description: "Output port for sending IPs",
+			Description: "Output port for the standard output of the command",
 			Required:    false,
 		},
 		library.EntryPort{
 			Name:        "ERR",
 			Type:        "string",
-			Description: "Output port for errors",
+			Description: "Output port for the standard error of the command and execution errors",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "EXIT",
+			Type:        "int",
+			Description: "Output port for the exit code of every execution (-1 if the command failed to start or timed out)",
 			Required:    false,
 		},
 	},
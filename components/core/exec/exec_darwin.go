@@ -1,12 +1,19 @@
 package main
 
 import (
-	"os"
 	"os/exec"
+	"syscall"
 )
 
-func executeCommand(command string) ([]byte, error) {
+// newCommand creates a shell command running in its own process group
+// (to kill it with all its children on timeout)
+func newCommand(command string) *exec.Cmd {
 	cmd := exec.Command("/bin/bash", "-c", command)
-	cmd.Env = os.Environ()
-	return cmd.Output()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// killCommand kills the process group of a started command
+func killCommand(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
 }
@@ -2,13 +2,14 @@ package main
 
 /*
 import (
-	"os"
 	"os/exec"
 )
 
-func executeCommand(command string) ([]byte, error) {
-	cmd := exec.Command("cmd", "/C", command)
-	cmd.Env = os.Environ()
-	return cmd.Output()
+func newCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
+
+func killCommand(cmd *exec.Cmd) {
+	cmd.Process.Kill()
 }
 */
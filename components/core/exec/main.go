@@ -2,12 +2,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -18,17 +19,21 @@ import (
 
 var (
 	// flags
-	cmdEndpoint    = flag.String("port.cmd", "", "Component's options port endpoint")
-	outputEndpoint = flag.String("port.out", "", "Component's output port endpoint")
-	errorEndpoint  = flag.String("port.err", "", "Component's output port endpoint")
-	jsonFlag       = flag.Bool("json", false, "Print component documentation in JSON")
-	debug          = flag.Bool("debug", false, "Enable debug mode")
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	cmdEndpoint     = flag.String("port.cmd", "", "Component's command port endpoint")
+	inputEndpoint   = flag.String("port.in", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.out", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	exitEndpoint    = flag.String("port.exit", "", "Component's exit code port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
 
 	// Internal
-	cmdPort, outPort, errPort *zmq.Socket
-	cmdCh, outCh, errCh       chan bool
-	exitCh                    chan os.Signal
-	err                       error
+	optionsPort, cmdPort, inPort, outPort, errPort, exitPort *zmq.Socket
+	cmdCh, inCh, outCh, errCh, exitPortCh                    chan bool
+	exitCh                                                   chan os.Signal
+	opts                                                     *options
+	err                                                      error
 )
 
 func main() {
@@ -40,19 +45,16 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
 	// Communication channels
 	cmdCh = make(chan bool)
+	inCh = make(chan bool)
 	outCh = make(chan bool)
 	errCh = make(chan bool)
+	exitPortCh = make(chan bool)
 	exitCh = make(chan os.Signal, 1)
 
 	// Start the communication & processing logic
@@ -71,15 +73,15 @@ func mainLoop() {
 	defer closePorts()
 
 	ports := 1
-	if outPort != nil {
-		ports++
-	}
-	if errPort != nil {
-		ports++
+	for _, p := range []*zmq.Socket{inPort, outPort, errPort, exitPort} {
+		if p != nil {
+			ports++
+		}
 	}
 
 	waitCh := make(chan bool)
 	cmdExitCh := make(chan bool, 1)
+	inExitCh := make(chan bool, 1)
 	go func(num int) {
 		total := 0
 		for {
@@ -88,8 +90,20 @@ func mainLoop() {
 				if v {
 					total++
 				} else {
-					cmdExitCh <- true
-					break
+					// non-blocking: the port may disconnect several times
+					select {
+					case cmdExitCh <- true:
+					default:
+					}
+				}
+			case v := <-inCh:
+				if v {
+					total++
+				} else {
+					select {
+					case inExitCh <- true:
+					default:
+					}
 				}
 			case v := <-outCh:
 				if !v {
@@ -107,6 +121,14 @@ func mainLoop() {
 				} else {
 					total++
 				}
+			case v := <-exitPortCh:
+				if !v {
+					log.Println("EXIT port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
 			}
 			if total >= num && waitCh != nil {
 				waitCh <- true
@@ -125,40 +147,147 @@ func mainLoop() {
 		return
 	}
 
+	opts = defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				sendError([]byte(err.Error()))
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
 	log.Println("Started...")
+	if inPort == nil {
+		// every received command is executed
+		for {
+			ip, err := cmdPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+				continue
+			}
+			run(string(ip[1]), nil)
+
+			select {
+			case <-cmdExitCh:
+				log.Println("CMD port is closed. Interrupting execution")
+				exitCh <- syscall.SIGTERM
+				return
+			default:
+				// CMD port is still open
+			}
+		}
+	}
+
+	// the command is configured by CMD and executed for every IP on IN
+	poller := zmq.NewPoller()
+	poller.Add(cmdPort, zmq.POLLIN)
+	poller.Add(inPort, zmq.POLLIN)
+	command := ""
 	for {
-		ip, err := cmdPort.RecvMessageBytes(0)
+		results, err := poller.Poll(time.Second)
 		if err != nil {
 			continue
 		}
-		if !runtime.IsValidIP(ip) {
-			continue
-		}
-		out, err := executeCommand(string(ip[1]))
-		if err != nil {
-			log.Println(err.Error())
-			if errPort != nil {
-				errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+		for _, r := range results {
+			ip, err := r.Socket.RecvMessageBytes(0)
+			if err != nil {
+				continue
 			}
-			continue
-		}
-		out = bytes.Replace(out, []byte("\n"), []byte(""), -1)
-		log.Println(string(out))
-		if outPort != nil {
-			outPort.SendMessage(runtime.NewPacket(out))
+			if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+				continue
+			}
+			if r.Socket == cmdPort {
+				command = string(ip[1])
+				log.Println("Using command:", command)
+				continue
+			}
+			if command == "" {
+				log.Println("ERROR: No command received on CMD port yet")
+				sendError([]byte("No command received on CMD port yet"))
+				continue
+			}
+			run(command, ip[1])
 		}
 
 		select {
-		case <-cmdExitCh:
-			log.Println("CMD port is closed. Interrupting execution")
+		case <-inExitCh:
+			log.Println("IN port is closed. Interrupting execution")
 			exitCh <- syscall.SIGTERM
-			break
+			return
 		default:
 			// IN port is still open
 		}
 	}
 }
 
+// run executes a command and sends its output, errors and exit code
+func run(command string, stdin []byte) {
+	log.Println("Executing:", command)
+	if opts.Output == outputLines && outPort != nil {
+		outPort.SendMessage(runtime.NewOpenBracket())
+	}
+	res := execute(command, stdin, opts, func(line []byte) {
+		log.Println(string(line))
+		if outPort != nil {
+			outPort.SendMessage(runtime.NewPacket(line))
+		}
+	})
+	switch {
+	case opts.Output == outputLines:
+		if outPort != nil {
+			outPort.SendMessage(runtime.NewCloseBracket())
+		}
+	case res.Err == nil:
+		out := res.Stdout
+		if opts.Output == outputJoined {
+			out = bytes.Replace(out, []byte("\n"), []byte(""), -1)
+		}
+		log.Println(string(out))
+		if outPort != nil {
+			outPort.SendMessage(runtime.NewPacket(out))
+		}
+	}
+
+	if stderr := bytes.TrimSpace(res.Stderr); len(stderr) > 0 {
+		sendError(stderr)
+	} else if res.Err != nil {
+		sendError([]byte(res.Err.Error()))
+	}
+	if res.Err != nil {
+		log.Println(res.Err.Error())
+	}
+	if exitPort != nil {
+		exitPort.SendMessage(runtime.NewPacket([]byte(strconv.Itoa(res.ExitCode))))
+	}
+}
+
+// sendError sends a given message to ERR port if it is connected
+func sendError(message []byte) {
+	if errPort != nil {
+		errPort.SendMessage(runtime.NewPacket(message))
+	}
+}
+
 // validateArgs checks all required flags
 func validateArgs() {
 	if *cmdEndpoint == "" {
@@ -169,9 +298,19 @@ func validateArgs() {
 
 // openPorts create ZMQ sockets and start socket monitoring loops
 func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("exec.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
 	cmdPort, err = utils.CreateInputPort("exec.cmd", *cmdEndpoint, cmdCh)
 	utils.AssertError(err)
 
+	if *inputEndpoint != "" {
+		inPort, err = utils.CreateInputPort("exec.in", *inputEndpoint, inCh)
+		utils.AssertError(err)
+	}
+
 	if *outputEndpoint != "" {
 		outPort, err = utils.CreateOutputPort("exec.out", *outputEndpoint, outCh)
 		utils.AssertError(err)
@@ -181,17 +320,21 @@ func openPorts() {
 		errPort, err = utils.CreateOutputPort("exec.err", *errorEndpoint, errCh)
 		utils.AssertError(err)
 	}
+
+	if *exitEndpoint != "" {
+		exitPort, err = utils.CreateOutputPort("exec.exit", *exitEndpoint, exitPortCh)
+		utils.AssertError(err)
+	}
 }
 
 // closePorts closes all active ports and terminates ZMQ context
 func closePorts() {
 	log.Println("Closing ports...")
 	cmdPort.Close()
-	if outPort != nil {
-		outPort.Close()
-	}
-	if errPort != nil {
-		errPort.Close()
+	for _, p := range []*zmq.Socket{inPort, outPort, errPort, exitPort} {
+		if p != nil {
+			p.Close()
+		}
 	}
 	zmq.Term()
 }
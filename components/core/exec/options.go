@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Output modes
+const (
+	// outputJoined sends the whole stdout as a single IP with newlines removed
+	outputJoined = "joined"
+	// outputWhole sends the whole stdout as a single IP as is
+	outputWhole = "whole"
+	// outputLines sends every line of stdout as it is printed within a substream
+	outputLines = "lines"
+)
+
+type options struct {
+	Dir     string            `json:"dir"`
+	Env     map[string]string `json:"env"`
+	Timeout string            `json:"timeout"`
+	Output  string            `json:"output"`
+
+	timeout time.Duration
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{Output: outputJoined}
+}
+
+func (o *options) Validate() error {
+	switch o.Output {
+	case "":
+		o.Output = outputJoined
+	case outputJoined, outputWhole, outputLines:
+	default:
+		return fmt.Errorf("Unknown output mode %s (should be joined, whole or lines)", o.Output)
+	}
+	if o.Timeout != "" {
+		d, err := time.ParseDuration(o.Timeout)
+		if err != nil {
+			return fmt.Errorf("Invalid timeout %s: %s", o.Timeout, err.Error())
+		}
+		o.timeout = d
+	}
+	if o.Dir != "" {
+		info, err := os.Stat(o.Dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("Working directory %s is not a directory", o.Dir)
+		}
+	}
+	return nil
+}
+
+// Environ returns the environment of the commands: the one of the component
+// with the variables from options added
+func (o *options) Environ() []string {
+	env := os.Environ()
+	for k, v := range o.Env {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
@@ -6,9 +6,17 @@ import (
 
 var registryEntry = &library.Entry{
 	Description: `Reads a given file from the local file system line by line and emits each line into the output port.
-The output data is sent as substream with open bracket IP in the beginning and close bracket IP at the end of the stream for each file.`,
+The output data is sent as substream with open bracket IP in the beginning and close bracket IP at the end of the stream for each file.
+Files may also be read in binary chunks or as a whole, .gz and .bz2 files are decompressed and the content is converted to UTF-8
+from the encoding given in OPTIONS (utf-8, latin1/iso-8859-1, windows-1252/cp1252, utf-16le, utf-16be; chunks are sent as is).`,
 	Elementary: true,
 	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"mode\": \"lines\", \"delimiter\": \"\\n\", \"chunk_size\": 65536, \"encoding\": \"latin1\", \"compression\": \"auto\"}",
+			Required:    false,
+		},
 		library.EntryPort{
 			Name:        "FILE",
 			Type:        "string",
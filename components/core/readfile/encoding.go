@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// decoders convert the content of a file in a given encoding into UTF-8
+var decoders = map[string]func(io.Reader) io.Reader{
+	"utf-8":        func(r io.Reader) io.Reader { return r },
+	"utf8":         func(r io.Reader) io.Reader { return r },
+	"latin1":       func(r io.Reader) io.Reader { return newByteDecoder(r, nil) },
+	"iso-8859-1":   func(r io.Reader) io.Reader { return newByteDecoder(r, nil) },
+	"windows-1252": func(r io.Reader) io.Reader { return newByteDecoder(r, windows1252) },
+	"cp1252":       func(r io.Reader) io.Reader { return newByteDecoder(r, windows1252) },
+	"utf-16le":     func(r io.Reader) io.Reader { return newUTF16Decoder(r, false) },
+	"utf-16be":     func(r io.Reader) io.Reader { return newUTF16Decoder(r, true) },
+}
+
+// windows1252 maps bytes 0x80-0x9F which differ from Latin-1
+var windows1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// runeDecoder is a reader producing UTF-8 from runes read by a function
+type runeDecoder struct {
+	next func() (rune, error)
+	buf  []byte
+	err  error
+}
+
+func (d *runeDecoder) Read(p []byte) (int, error) {
+	for len(d.buf) < len(p) && d.err == nil {
+		var r rune
+		r, d.err = d.next()
+		if d.err == nil {
+			var enc [utf8.UTFMax]byte
+			d.buf = append(d.buf, enc[:utf8.EncodeRune(enc[:], r)]...)
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	if n == 0 && d.err != nil {
+		return 0, d.err
+	}
+	return n, nil
+}
+
+// newByteDecoder decodes single-byte encodings: every byte is the code point
+// unless it is overridden in a given table
+func newByteDecoder(r io.Reader, table map[byte]rune) io.Reader {
+	br := bufio.NewReader(r)
+	return &runeDecoder{next: func() (rune, error) {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c, ok := table[b]; ok {
+			return c, nil
+		}
+		return rune(b), nil
+	}}
+}
+
+// newUTF16Decoder decodes UTF-16 (with surrogate pairs) of a given byte order
+func newUTF16Decoder(r io.Reader, bigEndian bool) io.Reader {
+	br := bufio.NewReader(r)
+	unit := func() (rune, error) {
+		var b [2]byte
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		if bigEndian {
+			return rune(b[0])<<8 | rune(b[1]), nil
+		}
+		return rune(b[1])<<8 | rune(b[0]), nil
+	}
+	var pending *rune
+	first := true
+	return &runeDecoder{next: func() (rune, error) {
+		var r1 rune
+		if pending != nil {
+			r1, pending = *pending, nil
+		} else {
+			var err error
+			if r1, err = unit(); err != nil {
+				return 0, err
+			}
+			// skip byte order mark
+			if first && r1 == 0xFEFF {
+				if r1, err = unit(); err != nil {
+					return 0, err
+				}
+			}
+		}
+		first = false
+		if !utf16.IsSurrogate(r1) {
+			return r1, nil
+		}
+		r2, err := unit()
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		if dec := utf16.DecodeRune(r1, r2); dec != utf8.RuneError {
+			return dec, nil
+		}
+		pending = &r2
+		return utf8.RuneError, nil
+	}}
+}
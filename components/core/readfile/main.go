@@ -2,8 +2,10 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -18,17 +20,19 @@ import (
 
 var (
 	// Flags
-	fileEndpoint   = flag.String("port.file", "", "Component's input port endpoint")
-	outputEndpoint = flag.String("port.out", "", "Component's output port endpoint")
-	errorEndpoint  = flag.String("port.err", "", "Component's error port endpoint")
-	jsonFlag       = flag.Bool("json", false, "Print component documentation in JSON")
-	debug          = flag.Bool("debug", false, "Enable debug mode")
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	fileEndpoint    = flag.String("port.file", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.out", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
 
 	// Internal
-	filePort, outPort, errPort *zmq.Socket
-	fileCh, outCh, errCh       chan bool
-	exitCh                     chan os.Signal
-	err                        error
+	optionsPort, filePort, outPort, errPort *zmq.Socket
+	fileCh, outCh, errCh                    chan bool
+	exitCh                                  chan os.Signal
+	opts                                    *options
+	err                                     error
 )
 
 func main() {
@@ -40,12 +44,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
@@ -124,6 +123,33 @@ func mainLoop() {
 		return
 	}
 
+	opts = defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				sendError(err)
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
 	log.Println("Started...")
 	for {
 		ip, err := filePort.RecvMessageBytes(zmq.DONTWAIT)
@@ -144,24 +170,19 @@ func mainLoop() {
 		}
 
 		filepath := string(ip[1])
-		f, err := os.Open(filepath)
+		f, err := openFile(filepath, opts)
 		if err != nil {
 			log.Printf("ERROR openning file %s: %s", filepath, err.Error())
-			if errPort != nil {
-				errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
-			}
+			sendError(err)
 			continue
 		}
 
 		outPort.SendMessage(runtime.NewOpenBracket())
 		outPort.SendMessage(ip)
 
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			outPort.SendMessage(runtime.NewPacket(scanner.Bytes()))
-		}
-		if err = scanner.Err(); err != nil && errPort != nil {
-			errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+		if err = readFile(f); err != nil {
+			log.Printf("ERROR reading file %s: %s", filepath, err.Error())
+			sendError(err)
 		}
 		f.Close()
 
@@ -169,6 +190,49 @@ func mainLoop() {
 	}
 }
 
+// readFile sends the content of a file according to the reading mode
+func readFile(f *file) error {
+	switch opts.Mode {
+	case modeWhole:
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		outPort.SendMessage(runtime.NewPacket(data))
+		return nil
+
+	case modeChunks:
+		buf := make([]byte, opts.ChunkSize)
+		for {
+			n, err := io.ReadFull(f, buf)
+			if n > 0 {
+				outPort.SendMessage(runtime.NewPacket(buf[:n]))
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxTokenSize)
+	scanner.Split(splitDelimiter(opts.Delimiter))
+	for scanner.Scan() {
+		outPort.SendMessage(runtime.NewPacket(scanner.Bytes()))
+	}
+	return scanner.Err()
+}
+
+// sendError sends an error to ERR port if it is connected
+func sendError(err error) {
+	if errPort != nil {
+		errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+	}
+}
+
 // validateArgs checks all required flags
 func validateArgs() {
 	if *fileEndpoint == "" {
@@ -183,6 +247,11 @@ func validateArgs() {
 
 // openPorts create ZMQ sockets and start socket monitoring loops
 func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("readfile.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
 	filePort, err = utils.CreateInputPort("readfile.file", *fileEndpoint, fileCh)
 	utils.AssertError(err)
 
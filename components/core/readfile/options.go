@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reading modes
+const (
+	// modeLines sends every line (separated by the delimiter) as an IP
+	modeLines = "lines"
+	// modeChunks sends the raw content in chunks of a fixed size
+	modeChunks = "chunks"
+	// modeWhole sends the whole content as a single IP
+	modeWhole = "whole"
+)
+
+// Compression of the files
+const (
+	// compressionAuto detects compression by the file extension (.gz, .bz2)
+	compressionAuto  = "auto"
+	compressionNone  = "none"
+	compressionGzip  = "gzip"
+	compressionBzip2 = "bzip2"
+)
+
+type options struct {
+	Mode        string `json:"mode"`
+	ChunkSize   int    `json:"chunk_size"`
+	Delimiter   string `json:"delimiter"`
+	Encoding    string `json:"encoding"`
+	Compression string `json:"compression"`
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{
+		Mode:        modeLines,
+		ChunkSize:   64 * 1024,
+		Delimiter:   "\n",
+		Encoding:    "utf-8",
+		Compression: compressionAuto,
+	}
+}
+
+func (o *options) Validate() error {
+	switch o.Mode {
+	case modeLines, modeChunks, modeWhole:
+	default:
+		return fmt.Errorf("Unknown mode %s (should be lines, chunks or whole)", o.Mode)
+	}
+	if o.ChunkSize <= 0 {
+		return fmt.Errorf("Chunk size should be positive")
+	}
+	if o.Delimiter == "" {
+		return fmt.Errorf("Delimiter should not be empty")
+	}
+	o.Encoding = strings.ToLower(o.Encoding)
+	if _, ok := decoders[o.Encoding]; !ok {
+		return fmt.Errorf("Unsupported encoding %s", o.Encoding)
+	}
+	switch o.Compression {
+	case compressionAuto, compressionNone, compressionGzip, compressionBzip2:
+	default:
+		return fmt.Errorf("Unknown compression %s (should be auto, none, gzip or bzip2)", o.Compression)
+	}
+	return nil
+}
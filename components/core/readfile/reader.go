@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// file is a reader of a decompressed and decoded file content
+type file struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the file and the decompressor
+func (f *file) Close() error {
+	var err error
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		if e := f.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// openFile opens a file for reading with given options
+func openFile(path string, opts *options) (*file, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	result := &file{Reader: f, closers: []io.Closer{f}}
+
+	compression := opts.Compression
+	if compression == compressionAuto {
+		switch {
+		case strings.HasSuffix(path, ".gz"):
+			compression = compressionGzip
+		case strings.HasSuffix(path, ".bz2"):
+			compression = compressionBzip2
+		}
+	}
+	switch compression {
+	case compressionGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		result.Reader = gz
+		result.closers = append(result.closers, gz)
+	case compressionBzip2:
+		result.Reader = bzip2.NewReader(f)
+	}
+
+	// binary chunks are sent as is
+	if opts.Mode != modeChunks {
+		result.Reader = decoders[opts.Encoding](result.Reader)
+	}
+	return result, nil
+}
+
+// maxTokenSize is the limit of a line length (practically unlimited)
+const maxTokenSize = math.MaxInt32
+
+// splitDelimiter returns a split function for bufio.Scanner separating
+// tokens by a given delimiter. Carriage returns are dropped from the end of
+// lines separated by newlines
+func splitDelimiter(delimiter string) func([]byte, bool) (int, []byte, error) {
+	delim := []byte(delimiter)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), trimToken(data[:i], delimiter), nil
+		}
+		if atEOF {
+			return len(data), trimToken(data, delimiter), nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func trimToken(token []byte, delimiter string) []byte {
+	if delimiter == "\n" {
+		return bytes.TrimSuffix(token, []byte("\r"))
+	}
+	return token
+}
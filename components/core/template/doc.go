@@ -5,19 +5,29 @@ import (
 )
 
 var registryEntry = &library.Entry{
-	Description: "Fill the template string with received data from the input port and pass it to the output port",
-	Elementary:  true,
+	Description: `Fill the template string with received data from the input port and pass it to the output port.
+Data may be any JSON value (object, array or scalar) available as . in the template. Besides the builtin functions
+templates can use: upper, lower, title, trim, replace OLD NEW, split SEP, join SEP, contains, hasPrefix, hasSuffix,
+repeat N; add, sub, mul, div, mod, min, max, round PLACES; now, date LAYOUT (time, UNIX seconds or RFC3339 string);
+json, default VALUE, coalesce. A new template can be sent to TPL at any time`,
+	Elementary: true,
 	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"engine\": \"html\"} escapes the data for HTML (default engine is text)",
+			Required:    false,
+		},
 		library.EntryPort{
 			Name:        "TPL",
 			Type:        "string",
-			Description: "Port for configuring component with a template",
+			Description: "Port for configuring component with a template (a new template replaces the current one)",
 			Required:    true,
 		},
 		library.EntryPort{
 			Name:        "IN",
 			Type:        "all",
-			Description: "Input port for receiving IPs",
+			Description: "Input port for receiving JSON data (substream brackets are passed through)",
 			Required:    true,
 		},
 	},
@@ -28,5 +38,11 @@ var registryEntry = &library.Entry{
 			Description: "Output port for sending IPs",
 			Required:    true,
 		},
+		library.EntryPort{
+			Name:        "ERR",
+			Type:        "string",
+			Description: "Error port for invalid templates, data and execution errors",
+			Required:    false,
+		},
 	},
 }
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// funcs are available in all templates in addition to the builtin functions
+var funcs = map[string]interface{}{
+	// strings
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"title":     strings.Title,
+	"trim":      strings.TrimSpace,
+	"replace":   func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":      join,
+	"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"repeat":    func(count int, s string) string { return strings.Repeat(s, count) },
+
+	// math (JSON numbers are float64)
+	"add":   arithmetic(func(a, b float64) float64 { return a + b }),
+	"sub":   arithmetic(func(a, b float64) float64 { return a - b }),
+	"mul":   arithmetic(func(a, b float64) float64 { return a * b }),
+	"div":   div,
+	"mod":   mod,
+	"min":   arithmetic(math.Min),
+	"max":   arithmetic(math.Max),
+	"round": round,
+
+	// dates
+	"now":  time.Now,
+	"date": date,
+
+	// JSON and defaults
+	"json":     toJSON,
+	"default":  defaultValue,
+	"coalesce": coalesce,
+}
+
+// join joins elements of a list (e.g. a JSON array) with a separator
+func join(sep string, list interface{}) (string, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// toFloat converts numbers and numeric strings to float64
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("Not a number: %v", v)
+}
+
+func arithmetic(op func(a, b float64) float64) func(a, b interface{}) (float64, error) {
+	return func(a, b interface{}) (float64, error) {
+		x, err := toFloat(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toFloat(b)
+		if err != nil {
+			return 0, err
+		}
+		return op(x, y), nil
+	}
+}
+
+func div(a, b interface{}) (float64, error) {
+	return divide(a, b, func(x, y float64) float64 { return x / y })
+}
+
+func mod(a, b interface{}) (float64, error) {
+	return divide(a, b, math.Mod)
+}
+
+func divide(a, b interface{}, op func(x, y float64) float64) (float64, error) {
+	if y, err := toFloat(b); err == nil && y == 0 {
+		return 0, fmt.Errorf("Division by zero")
+	}
+	return arithmetic(op)(a, b)
+}
+
+// round rounds a number to a given number of decimal places
+func round(places int, v interface{}) (float64, error) {
+	x, err := toFloat(v)
+	if err != nil {
+		return 0, err
+	}
+	p := math.Pow(10, float64(places))
+	return math.Round(x*p) / p, nil
+}
+
+// date formats a time given as time.Time, UNIX timestamp (seconds) or
+// RFC3339 string using a Go layout (e.g. 2006-01-02 15:04)
+func date(layout string, v interface{}) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	}
+	secs, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+	return time.Unix(0, int64(secs*float64(time.Second))).Format(layout), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// defaultValue returns def if v is empty (nil, zero, empty string/list/map)
+func defaultValue(def, v interface{}) interface{} {
+	if empty(v) {
+		return def
+	}
+	return v
+}
+
+// coalesce returns the first non-empty value
+func coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
@@ -5,12 +5,10 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
-	"text/template"
 	"time"
 
 	"github.com/cascades-fbp/cascades/components/utils"
@@ -20,17 +18,20 @@ import (
 
 var (
 	// Flags
-	tplEndpoint    = flag.String("port.tpl", "", "Component's options port endpoint")
-	inputEndpoint  = flag.String("port.in", "", "Component's input port endpoint")
-	outputEndpoint = flag.String("port.out", "", "Component's output port endpoint")
-	jsonFlag       = flag.Bool("json", false, "Print component documentation in JSON")
-	debug          = flag.Bool("debug", false, "Enable debug mode")
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	tplEndpoint     = flag.String("port.tpl", "", "Component's template port endpoint")
+	inputEndpoint   = flag.String("port.in", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.out", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
 
 	// Internal
-	tplPort, inPort, outPort *zmq.Socket
-	inCh, outCh              chan bool
-	exitCh                   chan os.Signal
-	err                      error
+	optionsPort, tplPort, inPort, outPort, errPort *zmq.Socket
+	inCh, outCh, errCh                             chan bool
+	exitCh                                         chan os.Signal
+	opts                                           *options
+	err                                            error
 )
 
 func main() {
@@ -42,18 +43,14 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
 	// Communication channels
 	inCh = make(chan bool)
 	outCh = make(chan bool)
+	errCh = make(chan bool)
 	exitCh = make(chan os.Signal, 1)
 
 	// Start the communication & processing logic
@@ -71,8 +68,13 @@ func mainLoop() {
 	openPorts()
 	defer closePorts()
 
+	ports := 2
+	if errPort != nil {
+		ports++
+	}
+
 	waitCh := make(chan bool)
-	go func() {
+	go func(num int) {
 		total := 0
 		for {
 			select {
@@ -92,12 +94,20 @@ func mainLoop() {
 				} else {
 					total++
 				}
+			case v := <-errCh:
+				if !v {
+					log.Println("ERR port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
 			}
-			if total >= 2 && waitCh != nil {
+			if total >= num && waitCh != nil {
 				waitCh <- true
 			}
 		}
-	}()
+	}(ports)
 
 	log.Println("Waiting for port connections to establish... ")
 	select {
@@ -110,58 +120,111 @@ func mainLoop() {
 		return
 	}
 
-	log.Println("Waiting for template...")
-	var (
-		t  *template.Template
-		ip [][]byte
-	)
-	for {
-		ip, err = tplPort.RecvMessageBytes(0)
-		if err != nil {
-			continue
-		}
-		if !runtime.IsValidIP(ip) {
-			log.Println("Invalid IP:", ip)
-			continue
+	opts = defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				sendError(err)
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
 		}
-		t = template.New("Current template")
-		t, err = t.Parse(string(ip[1]))
+		optionsPort.Close()
+	}
+
+	// IPs on IN wait in the queue until the first template is received
+	log.Println("Waiting for template...")
+	var t executor
+	for t == nil {
+		ip, err := tplPort.RecvMessageBytes(0)
 		if err != nil {
-			log.Println("Failed to configure component:", err.Error())
 			continue
 		}
-		break
+		t = configure(ip, t)
 	}
-	tplPort.Close()
 
 	log.Println("Started...")
-	var (
-		buf  *bytes.Buffer
-		data map[string]interface{}
-	)
+	poller := zmq.NewPoller()
+	poller.Add(tplPort, zmq.POLLIN)
+	poller.Add(inPort, zmq.POLLIN)
 	for {
-		ip, err := inPort.RecvMessageBytes(0)
+		results, err := poller.Poll(-1)
 		if err != nil {
 			continue
 		}
-		if !runtime.IsValidIP(ip) {
-			continue
-		}
+		for _, r := range results {
+			ip, err := r.Socket.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if r.Socket == tplPort {
+				t = configure(ip, t)
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				continue
+			}
+			if !runtime.IsPacket(ip) {
+				// substream brackets are passed through
+				outPort.SendMessage(ip)
+				continue
+			}
 
-		err = json.Unmarshal(ip[1], &data)
-		if err != nil {
-			log.Println(err.Error())
-			continue
-		}
+			var data interface{}
+			if err = json.Unmarshal(ip[1], &data); err != nil {
+				log.Println("ERROR: Invalid JSON:", err.Error())
+				sendError(fmt.Errorf("Invalid JSON: %s", err.Error()))
+				continue
+			}
 
-		buf = bytes.NewBufferString("")
-		err = t.Execute(buf, data)
-		if err != nil {
-			log.Println(err.Error())
-			continue
+			buf := bytes.NewBufferString("")
+			if err = t.Execute(buf, data); err != nil {
+				log.Println("ERROR:", err.Error())
+				sendError(err)
+				continue
+			}
+
+			outPort.SendMessage(runtime.NewPacket(buf.Bytes()))
 		}
+	}
+}
+
+// configure parses a template received on TPL port. The current template
+// is kept if the new one is invalid
+func configure(ip [][]byte, current executor) executor {
+	if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+		log.Println("Invalid IP:", ip)
+		return current
+	}
+	t, err := parseTemplate(string(ip[1]), opts.Engine)
+	if err != nil {
+		log.Println("Failed to configure component:", err.Error())
+		sendError(err)
+		return current
+	}
+	log.Println("Template configured")
+	return t
+}
 
-		outPort.SendMessage(runtime.NewPacket(buf.Bytes()))
+// sendError sends an error to ERR port if it is connected
+func sendError(err error) {
+	if errPort != nil {
+		errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
 	}
 }
 
@@ -183,6 +246,11 @@ func validateArgs() {
 
 // openPorts create ZMQ sockets and start socket monitoring loops
 func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("template.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
 	tplPort, err = utils.CreateInputPort("template.tpl", *tplEndpoint, nil)
 	utils.AssertError(err)
 
@@ -191,6 +259,11 @@ func openPorts() {
 
 	outPort, err = utils.CreateOutputPort("template.out", *outputEndpoint, outCh)
 	utils.AssertError(err)
+
+	if *errorEndpoint != "" {
+		errPort, err = utils.CreateOutputPort("template.err", *errorEndpoint, errCh)
+		utils.AssertError(err)
+	}
 }
 
 // closePorts closes all active ports and terminates ZMQ context
@@ -199,5 +272,8 @@ func closePorts() {
 	tplPort.Close()
 	inPort.Close()
 	outPort.Close()
+	if errPort != nil {
+		errPort.Close()
+	}
 	zmq.Term()
 }
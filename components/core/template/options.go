@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Template engines
+const (
+	engineText = "text"
+	engineHTML = "html"
+)
+
+type options struct {
+	Engine string `json:"engine"`
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{Engine: engineText}
+}
+
+func (o *options) Validate() error {
+	switch o.Engine {
+	case "":
+		o.Engine = engineText
+	case engineText, engineHTML:
+	default:
+		return fmt.Errorf("Unknown engine %s (should be text or html)", o.Engine)
+	}
+	return nil
+}
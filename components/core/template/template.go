@@ -0,0 +1,21 @@
+package main
+
+import (
+	htmltemplate "html/template"
+	"io"
+	texttemplate "text/template"
+)
+
+// executor is implemented by both text and html templates
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parseTemplate parses a template for a given engine. The html engine
+// escapes the data according to the context (see html/template)
+func parseTemplate(text, engine string) (executor, error) {
+	if engine == engineHTML {
+		return htmltemplate.New("template").Funcs(funcs).Parse(text)
+	}
+	return texttemplate.New("template").Funcs(funcs).Parse(text)
+}
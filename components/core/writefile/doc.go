@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/cascades-fbp/cascades/library"
+)
+
+var registryEntry = &library.Entry{
+	Description: `Writes received IPs into files (followed by the delimiter, a newline by default). The file path is a template
+(text/template) evaluated for every IP with .Time, .Date (2006-01-02), .Hour (15) and .Seq (number of the substream),
+e.g. /var/data/out-{{.Date}}.log starts a new file every day. Files may be written one per substream, gzipped, synced
+periodically and rotated by size (on disk, i.e. compressed with gzip) or age (rotated files get a timestamp suffix).
+With per_substream the template must use {{.Seq}}`,
+	Elementary: true,
+	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"file\": \"/tmp/out-{{.Seq}}.txt\", \"mode\": \"truncate\", \"per_substream\": true, \"gzip\": false, \"delimiter\": \"\\n\", \"sync\": \"5s\", \"max_size\": 10485760, \"max_age\": \"1h\"}",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "FILE",
+			Type:        "string",
+			Description: "Port for the file path template (alternatively given in OPTIONS)",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "IN",
+			Type:        "all",
+			Description: "Input port for IPs to write",
+			Required:    true,
+		},
+	},
+	Outports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "ERR",
+			Type:        "string",
+			Description: "Error port for errors opening/writing files",
+			Required:    false,
+		},
+	},
+}
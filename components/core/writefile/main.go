@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+	"github.com/cascades-fbp/cascades/runtime"
+	zmq "github.com/pebbe/zmq4"
+)
+
+var (
+	// Flags
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	fileEndpoint    = flag.String("port.file", "", "Component's file port endpoint")
+	inputEndpoint   = flag.String("port.in", "", "Component's input port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
+
+	// Internal
+	optionsPort, filePort, inPort, errPort *zmq.Socket
+	inCh, errCh                            chan bool
+	exitCh                                 chan os.Signal
+	opts                                   *options
+	err                                    error
+
+	// out is closed by main on termination (flushing gzip data and syncing
+	// the file), so it is guarded by outMx
+	out   *sink
+	outMx sync.Mutex
+
+	// depth of the current substream on IN
+	depth int
+)
+
+func main() {
+	flag.Parse()
+
+	if *jsonFlag {
+		doc, _ := registryEntry.JSON()
+		fmt.Println(string(doc))
+		os.Exit(0)
+	}
+
+	utils.SetupLogging(*debug)
+
+	validateArgs()
+
+	// Communication channels
+	inCh = make(chan bool)
+	errCh = make(chan bool)
+	exitCh = make(chan os.Signal, 1)
+
+	// Start the communication & processing logic
+	go mainLoop()
+
+	// Wait for the end...
+	signal.Notify(exitCh, os.Interrupt, syscall.SIGTERM)
+	<-exitCh
+
+	if err := closeSink(); err != nil {
+		log.Println("ERROR closing file:", err.Error())
+	}
+
+	log.Println("Done")
+}
+
+// mainLoop initiates all ports and handles the traffic
+func mainLoop() {
+	openPorts()
+	defer closePorts()
+
+	ports := 1
+	if errPort != nil {
+		ports++
+	}
+
+	waitCh := make(chan bool)
+	inExitCh := make(chan bool, 1)
+	go func(num int) {
+		total := 0
+		for {
+			select {
+			case v := <-inCh:
+				if v {
+					total++
+				} else {
+					inExitCh <- true
+				}
+			case v := <-errCh:
+				if !v {
+					log.Println("ERR port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+			}
+			if total >= num && waitCh != nil {
+				waitCh <- true
+			}
+		}
+	}(ports)
+
+	log.Println("Waiting for port connections to establish... ")
+	select {
+	case <-waitCh:
+		log.Println("Ports connected")
+		waitCh = nil
+	case <-time.Tick(30 * time.Second):
+		log.Println("Timeout: port connections were not established within provided interval")
+		exitCh <- syscall.SIGTERM
+		return
+	}
+
+	opts = defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				sendError(err)
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
+	if filePort != nil {
+		log.Println("Waiting for file template...")
+		for {
+			ip, err := filePort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts.File = string(ip[1])
+			break
+		}
+		filePort.Close()
+	}
+
+	s, err := newSink(opts.File, opts)
+	if err != nil {
+		log.Println("ERROR:", err.Error())
+		sendError(err)
+		exitCh <- syscall.SIGTERM
+		return
+	}
+	outMx.Lock()
+	out = s
+	outMx.Unlock()
+
+	log.Println("Started...")
+	poller := zmq.NewPoller()
+	poller.Add(inPort, zmq.POLLIN)
+	lastSync := time.Now()
+	for {
+		if opts.sync > 0 && time.Since(lastSync) >= opts.sync {
+			outMx.Lock()
+			if out != nil {
+				if err = out.Sync(); err != nil {
+					log.Println("ERROR syncing file:", err.Error())
+					sendError(err)
+				}
+			}
+			outMx.Unlock()
+			lastSync = time.Now()
+		}
+
+		results, err := poller.Poll(time.Second)
+		if err != nil || len(results) == 0 {
+			select {
+			case <-inExitCh:
+				log.Println("IN port is closed. Interrupting execution")
+				if err = closeSink(); err != nil {
+					log.Println("ERROR closing file:", err.Error())
+					sendError(err)
+				}
+				exitCh <- syscall.SIGTERM
+				return
+			default:
+				// IN port is still open
+			}
+			continue
+		}
+
+		ip, err := inPort.RecvMessageBytes(0)
+		if err != nil || !runtime.IsValidIP(ip) {
+			continue
+		}
+
+		handle(ip)
+	}
+}
+
+// handle writes a packet or starts/ends a substream
+func handle(ip [][]byte) {
+	outMx.Lock()
+	defer outMx.Unlock()
+	if out == nil {
+		// closed on termination
+		return
+	}
+
+	var err error
+	switch {
+	case runtime.IsOpenBracket(ip):
+		depth++
+		if opts.PerSubstream && depth == 1 {
+			err = out.StartSubstream()
+		}
+	case runtime.IsCloseBracket(ip):
+		if depth > 0 {
+			depth--
+		}
+		if opts.PerSubstream && depth == 0 {
+			err = out.EndSubstream()
+		}
+	default:
+		err = out.Write(ip[1])
+	}
+	if err != nil {
+		log.Println("ERROR writing file:", err.Error())
+		sendError(err)
+	}
+}
+
+// closeSink closes the current file. Nothing is written after that
+func closeSink() error {
+	outMx.Lock()
+	defer outMx.Unlock()
+	if out == nil {
+		return nil
+	}
+	err := out.Close()
+	out = nil
+	return err
+}
+
+// sendError sends an error to ERR port if it is connected
+func sendError(err error) {
+	if errPort != nil {
+		errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+	}
+}
+
+// validateArgs checks all required flags
+func validateArgs() {
+	if *inputEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *fileEndpoint == "" && *optionsEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+// openPorts create ZMQ sockets and start socket monitoring loops
+func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("writefile.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
+	if *fileEndpoint != "" {
+		filePort, err = utils.CreateInputPort("writefile.file", *fileEndpoint, nil)
+		utils.AssertError(err)
+	}
+
+	inPort, err = utils.CreateInputPort("writefile.in", *inputEndpoint, inCh)
+	utils.AssertError(err)
+
+	if *errorEndpoint != "" {
+		errPort, err = utils.CreateOutputPort("writefile.err", *errorEndpoint, errCh)
+		utils.AssertError(err)
+	}
+}
+
+// closePorts closes all active ports and terminates ZMQ context
+func closePorts() {
+	log.Println("Closing ports...")
+	inPort.Close()
+	if errPort != nil {
+		errPort.Close()
+	}
+	zmq.Term()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Modes of opening existing files
+const (
+	modeAppend   = "append"
+	modeTruncate = "truncate"
+)
+
+type options struct {
+	File         string `json:"file"`
+	Mode         string `json:"mode"`
+	PerSubstream bool   `json:"per_substream"`
+	Gzip         bool   `json:"gzip"`
+	Delimiter    string `json:"delimiter"`
+	Sync         string `json:"sync"`
+	MaxSize      int64  `json:"max_size"`
+	MaxAge       string `json:"max_age"`
+
+	sync   time.Duration
+	maxAge time.Duration
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{Mode: modeAppend, Delimiter: "\n"}
+}
+
+func (o *options) Validate() error {
+	switch o.Mode {
+	case "":
+		o.Mode = modeAppend
+	case modeAppend, modeTruncate:
+	default:
+		return fmt.Errorf("Unknown mode %s (should be append or truncate)", o.Mode)
+	}
+	var err error
+	if o.sync, err = parseDuration("sync", o.Sync); err != nil {
+		return err
+	}
+	if o.maxAge, err = parseDuration("max_age", o.MaxAge); err != nil {
+		return err
+	}
+	if o.MaxSize < 0 {
+		o.MaxSize = 0
+	}
+	if o.File != "" {
+		return checkTemplate(o.File, o)
+	}
+	return nil
+}
+
+// checkTemplate makes sure substreams are written into different files
+func checkTemplate(file string, o *options) error {
+	if o.PerSubstream && !strings.Contains(file, ".Seq") {
+		return fmt.Errorf("File template %s should use {{.Seq}} to write a file per substream", file)
+	}
+	return nil
+}
+
+func parseDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s %s: %s", name, value, err.Error())
+	}
+	return d, nil
+}
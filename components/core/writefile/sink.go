@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// pathData is passed to the path template
+type pathData struct {
+	Time time.Time
+	Date string
+	Hour string
+	Seq  int
+}
+
+// sink writes data into the files given by a path template
+type sink struct {
+	opts *options
+	tmpl *template.Template
+	seq  int
+	seen map[string]bool
+
+	path   string
+	file   *os.File
+	gz     *gzip.Writer
+	w      io.Writer
+	size   int64 // bytes in the file (compressed with gzip)
+	opened time.Time
+	dirty  bool
+}
+
+func newSink(pathTemplate string, opts *options) (*sink, error) {
+	if pathTemplate == "" {
+		return nil, fmt.Errorf("File template is not given (neither by FILE nor by OPTIONS)")
+	}
+	if err := checkTemplate(pathTemplate, opts); err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("path").Parse(pathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid file template: %s", err.Error())
+	}
+	return &sink{
+		opts: opts,
+		tmpl: tmpl,
+		seen: map[string]bool{},
+	}, nil
+}
+
+// Write writes data (followed by the delimiter) into the current file
+func (s *sink) Write(data []byte) error {
+	now := time.Now()
+	path, err := s.Path(now)
+	if err != nil {
+		return err
+	}
+	if path != s.path {
+		if err = s.Close(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		if err = s.open(path, now); err != nil {
+			return err
+		}
+	} else if s.rotationDue(now) {
+		if err = s.rotate(now); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.w.Write(append(data, s.opts.Delimiter...))
+	s.dirty = true
+	return err
+}
+
+// Path evaluates the path template
+func (s *sink) Path(now time.Time) (string, error) {
+	var buf bytes.Buffer
+	err := s.tmpl.Execute(&buf, pathData{
+		Time: now,
+		Date: now.Format("2006-01-02"),
+		Hour: now.Format("15"),
+		Seq:  s.seq,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to evaluate file template: %s", err.Error())
+	}
+	return buf.String(), nil
+}
+
+// StartSubstream starts a new file for a substream
+func (s *sink) StartSubstream() error {
+	s.seq++
+	return s.Close()
+}
+
+// EndSubstream closes the file of a substream
+func (s *sink) EndSubstream() error {
+	return s.Close()
+}
+
+// Sync flushes written data to the disk
+func (s *sink) Sync() error {
+	if s.file == nil || !s.dirty {
+		return nil
+	}
+	if s.gz != nil {
+		if err := s.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	s.dirty = false
+	return s.file.Sync()
+}
+
+// Close closes the current file
+func (s *sink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	var err error
+	if s.gz != nil {
+		err = s.gz.Close()
+	}
+	if e := s.file.Close(); err == nil {
+		err = e
+	}
+	s.file, s.gz, s.w = nil, nil, nil
+	s.dirty = false
+	return err
+}
+
+// open opens a file truncating it on first use in truncate mode
+func (s *sink) open(path string, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if s.opts.Mode == modeTruncate && !s.seen[path] {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	log.Println("Writing to", path)
+	s.seen[path] = true
+	s.path = path
+	s.file = file
+	s.w = &countingWriter{w: file, n: &s.size}
+	if s.opts.Gzip {
+		s.gz = gzip.NewWriter(s.w)
+		s.w = s.gz
+	}
+	s.size = info.Size()
+	s.opened = now
+	return nil
+}
+
+func (s *sink) rotationDue(now time.Time) bool {
+	return (s.opts.MaxSize > 0 && s.size >= s.opts.MaxSize) ||
+		(s.opts.maxAge > 0 && now.Sub(s.opened) >= s.opts.maxAge)
+}
+
+// rotate renames the current file adding a timestamp and starts a new one
+func (s *sink) rotate(now time.Time) error {
+	path := s.path
+	if err := s.Close(); err != nil {
+		return err
+	}
+	rotated := rotatedName(path, now)
+	for i := 1; exists(rotated); i++ {
+		rotated = rotatedName(path, now) + fmt.Sprintf(".%v", i)
+	}
+	log.Printf("Rotating %s to %s", path, rotated)
+	if err := os.Rename(path, rotated); err != nil {
+		return err
+	}
+	return s.open(path, now)
+}
+
+// rotatedName inserts a timestamp into the path (before .gz extension)
+func rotatedName(path string, now time.Time) string {
+	stamp := now.Format("20060102-150405")
+	if strings.HasSuffix(path, ".gz") {
+		return strings.TrimSuffix(path, ".gz") + "." + stamp + ".gz"
+	}
+	return path + "." + stamp
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// countingWriter counts bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/cascades-fbp/cascades/library"
+)
+
+var registryEntry = &library.Entry{
+	Description: `Follows files like tail -F: sends every line appended to a file received on FILE port into the output port.
+Rotated and truncated files are reopened from the beginning. Offsets of the files can be saved to a state file (see OPTIONS)
+to resume after a restart where it left off`,
+	Elementary: true,
+	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"state\": \"/var/lib/cascades/tail.json\", \"from\": \"start\", \"poll\": \"500ms\"}",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "FILE",
+			Type:        "string",
+			Description: "Port for setting paths of the files to follow",
+			Required:    true,
+		},
+	},
+	Outports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OUT",
+			Type:        "string",
+			Description: "Output port for the appended lines",
+			Required:    true,
+		},
+		library.EntryPort{
+			Name:        "ERR",
+			Type:        "string",
+			Description: "Error port for errors opening/reading files",
+			Required:    false,
+		},
+	},
+}
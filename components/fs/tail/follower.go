@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// follower follows a single file sending complete lines to a callback
+type follower struct {
+	path  string
+	opts  *options
+	state *state
+	line  func([]byte)
+	fail  func(error)
+
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	started bool
+	missing bool
+}
+
+// Run polls the file until stopped
+func (f *follower) Run(stop <-chan bool) {
+	ticker := time.NewTicker(f.opts.poll)
+	defer ticker.Stop()
+	defer f.close()
+	for {
+		f.poll()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads appended data and checks the file for rotation and truncation
+func (f *follower) poll() {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			if !f.missing {
+				log.Println("ERROR:", err.Error())
+				f.fail(err)
+				f.missing = true
+			}
+			return
+		}
+		f.missing = false
+	}
+
+	if err := f.read(); err != nil {
+		log.Println("ERROR:", err.Error())
+		f.fail(err)
+	}
+
+	info, err := os.Stat(f.path)
+	switch {
+	case err != nil:
+		// removed: keep the old file until a new one appears
+	case !os.SameFile(info, f.info):
+		log.Println("File rotated:", f.path)
+		// the rest of the old file (including incomplete last line)
+		f.read()
+		if len(f.partial) > 0 {
+			f.emit(f.partial)
+			f.partial = nil
+		}
+		f.close()
+	case info.Size() < f.offset:
+		log.Println("File truncated:", f.path)
+		if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+			f.fail(err)
+			f.close()
+			return
+		}
+		f.info = info
+		f.offset = 0
+		f.partial = nil
+		f.save()
+	}
+}
+
+// open opens the file at the saved offset (if it is the same file), at its
+// end or beginning when started and at the beginning after rotation
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		f.started = true
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	offset := int64(0)
+	if !f.started {
+		if p, ok := f.saved(); ok && p.ID == fileID(info) && p.Offset <= info.Size() {
+			offset = p.Offset
+		} else if f.opts.From == fromEnd {
+			offset = info.Size()
+		}
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	log.Printf("Following %s from offset %v", f.path, offset)
+
+	f.file = file
+	f.info = info
+	f.offset = offset
+	f.partial = nil
+	f.started = true
+	f.save()
+	return nil
+}
+
+// read sends all complete lines appended since the last read
+func (f *follower) read() error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.file.Read(buf)
+		if n > 0 {
+			f.offset += int64(n)
+			f.partial = append(f.partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(f.partial, '\n')
+				if i < 0 {
+					break
+				}
+				f.emit(bytes.TrimSuffix(f.partial[:i], []byte("\r")))
+				f.partial = f.partial[i+1:]
+			}
+		}
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// do not keep the consumed part of the buffer
+	f.partial = append([]byte(nil), f.partial...)
+	f.save()
+	return nil
+}
+
+// emit passes a copy of a line to the callback
+func (f *follower) emit(line []byte) {
+	f.line(append([]byte(nil), line...))
+}
+
+// saved returns the saved position of the file if state is enabled
+func (f *follower) saved() (position, bool) {
+	if f.state == nil {
+		return position{}, false
+	}
+	return f.state.Get(f.path)
+}
+
+// save saves the offset of the last complete line
+func (f *follower) save() {
+	if f.state == nil || f.file == nil {
+		return
+	}
+	p := position{ID: fileID(f.info), Offset: f.offset - int64(len(f.partial))}
+	if err := f.state.Set(f.path, p); err != nil {
+		log.Println("ERROR saving state:", err.Error())
+		f.fail(err)
+	}
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+	"github.com/cascades-fbp/cascades/runtime"
+	zmq "github.com/pebbe/zmq4"
+)
+
+var (
+	// Flags
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	fileEndpoint    = flag.String("port.file", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.out", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
+
+	// Internal
+	optionsPort, filePort, outPort, errPort *zmq.Socket
+	outCh, errCh                            chan bool
+	exitCh                                  chan os.Signal
+	err                                     error
+)
+
+func main() {
+	flag.Parse()
+
+	if *jsonFlag {
+		doc, _ := registryEntry.JSON()
+		fmt.Println(string(doc))
+		os.Exit(0)
+	}
+
+	utils.SetupLogging(*debug)
+
+	validateArgs()
+
+	// Communication channels
+	outCh = make(chan bool)
+	errCh = make(chan bool)
+	exitCh = make(chan os.Signal, 1)
+
+	// Start the communication & processing logic
+	go mainLoop()
+
+	// Wait for the end...
+	signal.Notify(exitCh, os.Interrupt, syscall.SIGTERM)
+	<-exitCh
+
+	log.Println("Done")
+}
+
+// mainLoop initiates all ports and handles the traffic
+func mainLoop() {
+	openPorts()
+	defer closePorts()
+
+	ports := 1
+	if errPort != nil {
+		ports++
+	}
+
+	waitCh := make(chan bool)
+	go func(num int) {
+		total := 0
+		for {
+			select {
+			case v := <-outCh:
+				if !v {
+					log.Println("OUT port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+			case v := <-errCh:
+				if !v {
+					log.Println("ERR port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+			}
+			if total >= num && waitCh != nil {
+				waitCh <- true
+			}
+		}
+	}(ports)
+
+	log.Println("Waiting for port connections to establish... ")
+	select {
+	case <-waitCh:
+		log.Println("Ports connected")
+		waitCh = nil
+	case <-time.Tick(30 * time.Second):
+		log.Println("Timeout: port connections were not established within provided interval")
+		exitCh <- syscall.SIGTERM
+		return
+	}
+
+	lines := make(chan []byte)
+	errors := make(chan error)
+
+	opts := defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
+	var st *state
+	if opts.State != "" {
+		if st, err = loadState(opts.State); err != nil {
+			log.Println("ERROR: Failed to load state:", err.Error())
+			exitCh <- syscall.SIGTERM
+			return
+		}
+	}
+
+	// Receive files to follow (every file is followed in its own goroutine)
+	stop := make(chan bool)
+	defer close(stop)
+	go func() {
+		followed := map[string]bool{}
+		for {
+			ip, err := filePort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
+				continue
+			}
+			path, err := filepath.Abs(string(ip[1]))
+			if err != nil {
+				errors <- err
+				continue
+			}
+			if followed[path] {
+				continue
+			}
+			followed[path] = true
+			f := &follower{
+				path:  path,
+				opts:  opts,
+				state: st,
+				line:  func(data []byte) { lines <- data },
+				fail:  func(err error) { errors <- err },
+			}
+			go f.Run(stop)
+		}
+	}()
+
+	log.Println("Started...")
+	for {
+		select {
+		case data := <-lines:
+			outPort.SendMessage(runtime.NewPacket(data))
+		case err := <-errors:
+			if errPort != nil {
+				errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+			}
+		}
+	}
+}
+
+// validateArgs checks all required flags
+func validateArgs() {
+	if *fileEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *outputEndpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+}
+
+// openPorts create ZMQ sockets and start socket monitoring loops
+func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("fs/tail.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
+	filePort, err = utils.CreateInputPort("fs/tail.file", *fileEndpoint, nil)
+	utils.AssertError(err)
+
+	outPort, err = utils.CreateOutputPort("fs/tail.out", *outputEndpoint, outCh)
+	utils.AssertError(err)
+
+	if *errorEndpoint != "" {
+		errPort, err = utils.CreateOutputPort("fs/tail.err", *errorEndpoint, errCh)
+		utils.AssertError(err)
+	}
+}
+
+// closePorts closes all active ports and terminates ZMQ context
+func closePorts() {
+	log.Println("Closing ports...")
+	filePort.Close()
+	outPort.Close()
+	if errPort != nil {
+		errPort.Close()
+	}
+	zmq.Term()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Where to start reading a file which has no saved offset
+const (
+	fromStart = "start"
+	fromEnd   = "end"
+)
+
+type options struct {
+	State string `json:"state"`
+	From  string `json:"from"`
+	Poll  string `json:"poll"`
+
+	poll time.Duration
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{From: fromEnd, poll: time.Second}
+}
+
+func (o *options) Validate() error {
+	switch o.From {
+	case "":
+		o.From = fromEnd
+	case fromStart, fromEnd:
+	default:
+		return fmt.Errorf("Unknown starting point %s (should be start or end)", o.From)
+	}
+	o.poll = time.Second
+	if o.Poll != "" {
+		d, err := time.ParseDuration(o.Poll)
+		if err != nil {
+			return fmt.Errorf("Invalid poll interval %s: %s", o.Poll, err.Error())
+		}
+		if d <= 0 {
+			return fmt.Errorf("Poll interval should be positive")
+		}
+		o.poll = d
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// position is a saved offset of a file
+type position struct {
+	ID     uint64 `json:"id"`
+	Offset int64  `json:"offset"`
+}
+
+// state keeps offsets of the followed files in a JSON file
+type state struct {
+	path      string
+	mutex     sync.Mutex
+	positions map[string]position
+}
+
+// loadState reads a state file (a missing file means an empty state)
+func loadState(path string) (*state, error) {
+	s := &state{path: path, positions: map[string]position{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &s.positions); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns a saved position of a file
+func (s *state) Get(file string) (position, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, ok := s.positions[file]
+	return p, ok
+}
+
+// Set updates a position of a file and saves the state (atomically
+// replacing the state file)
+func (s *state) Set(file string, p position) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.positions[file] == p {
+		return nil
+	}
+	s.positions[file] = p
+	data, err := json.MarshalIndent(s.positions, "", "   ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -5,9 +5,16 @@ import (
 )
 
 var registryEntry = &library.Entry{
-	Description: "Recursively walks a given directory and sends filepaths into the output port (only files, directories are omitted)",
-	Elementary:  true,
+	Description: `Recursively walks a given directory and sends filepaths into the output port (only files, directories are omitted).
+Files of every received directory are sent as a substream with open bracket IP in the beginning and close bracket IP at the end`,
+	Elementary: true,
 	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"include\": [\"*.go\"], \"exclude\": [\"vendor\"], \"max_depth\": 2, \"follow_symlinks\": true, \"skip_hidden\": true, \"json\": true}",
+			Required:    false,
+		},
 		library.EntryPort{
 			Name:        "DIR",
 			Type:        "string",
@@ -19,7 +26,7 @@ var registryEntry = &library.Entry{
 		library.EntryPort{
 			Name:        "FILE",
 			Type:        "string",
-			Description: "...",
+			Description: "Output port for file paths (or JSON descriptions with path, size, mtime and mode)",
 			Required:    true,
 		},
 		library.EntryPort{
@@ -1,13 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
@@ -18,18 +17,20 @@ import (
 
 var (
 	// Flags
-	inputEndpoint  = flag.String("port.dir", "", "Component's input port endpoint")
-	outputEndpoint = flag.String("port.file", "", "Component's output port endpoint")
-	errorEndpoint  = flag.String("port.err", "", "Component's error port endpoint")
-	jsonFlag       = flag.Bool("json", false, "Print component documentation in JSON")
-	debug          = flag.Bool("debug", false, "Enable debug mode")
+	optionsEndpoint = flag.String("port.options", "", "Component's options port endpoint")
+	inputEndpoint   = flag.String("port.dir", "", "Component's input port endpoint")
+	outputEndpoint  = flag.String("port.file", "", "Component's output port endpoint")
+	errorEndpoint   = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag        = flag.Bool("json", false, "Print component documentation in JSON")
+	debug           = flag.Bool("debug", false, "Enable debug mode")
 
 	// Internal
-	inPort, outPort, errPort *zmq.Socket
-	inCh, outCh, errCh       chan bool
-	exitCh                   chan os.Signal
-	ip                       [][]byte
-	err                      error
+	optionsPort, inPort, outPort, errPort *zmq.Socket
+	inCh, outCh, errCh                    chan bool
+	exitCh                                chan os.Signal
+	opts                                  *options
+	ip                                    [][]byte
+	err                                   error
 )
 
 func main() {
@@ -41,12 +42,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
@@ -125,6 +121,44 @@ func mainLoop() {
 		break
 	}
 
+	opts = &options{}
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = &options{}
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				sendError(err)
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
+	w := &walker{
+		opts: opts,
+		file: func(path string, info os.FileInfo) {
+			outPort.SendMessage(runtime.NewPacket(describe(path, info, opts.JSON)))
+		},
+		fail: func(err error) {
+			log.Println("ERROR:", err.Error())
+			sendError(err)
+		},
+	}
+
 	log.Println("Started...")
 	for {
 		ip, err := inPort.RecvMessageBytes(zmq.DONTWAIT)
@@ -140,24 +174,18 @@ func mainLoop() {
 			time.Sleep(2 * time.Second)
 			continue
 		}
-		if !runtime.IsValidIP(ip) {
+		if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
 			continue
 		}
+
+		// Files of every directory are sent as a substream
 		dir := string(ip[1])
-		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() {
-				outPort.SendMessage(runtime.NewPacket([]byte(path)))
-			}
-			return nil
-		})
+		outPort.SendMessage(runtime.NewOpenBracket())
+		err = w.Walk(dir)
+		outPort.SendMessage(runtime.NewCloseBracket())
 		if err != nil {
-			log.Printf("ERROR openning file %s: %s", dir, err.Error())
-			if errPort != nil {
-				errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
-			}
+			log.Printf("ERROR openning directory %s: %s", dir, err.Error())
+			sendError(err)
 			continue
 		}
 
@@ -172,6 +200,13 @@ func mainLoop() {
 	}
 }
 
+// sendError sends an error to ERR port if it is connected
+func sendError(err error) {
+	if errPort != nil {
+		errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
+	}
+}
+
 // validateArgs checks all required flags
 func validateArgs() {
 	if *inputEndpoint == "" {
@@ -186,6 +221,11 @@ func validateArgs() {
 
 // openPorts create ZMQ sockets and start socket monitoring loops
 func openPorts() {
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("fs/walk.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
 	inPort, err = utils.CreateInputPort("fs/walk.dir", *inputEndpoint, inCh)
 	utils.AssertError(err)
 
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+)
+
+type options struct {
+	Include        []string `json:"include"`
+	Exclude        []string `json:"exclude"`
+	MaxDepth       int      `json:"max_depth"`
+	FollowSymlinks bool     `json:"follow_symlinks"`
+	SkipHidden     bool     `json:"skip_hidden"`
+	JSON           bool     `json:"json"`
+}
+
+func (o *options) Validate() error {
+	if err := utils.CheckPatterns(append(append([]string{}, o.Include...), o.Exclude...)); err != nil {
+		return err
+	}
+	if o.MaxDepth < 0 {
+		o.MaxDepth = 0
+	}
+	return nil
+}
+
+// Skips checks if a file or directory (path relative to the walked
+// directory) should be skipped because it is hidden or excluded
+func (o *options) Skips(rel string) bool {
+	if o.SkipHidden && strings.HasPrefix(filepath.Base(rel), ".") {
+		return true
+	}
+	return utils.MatchAny(o.Exclude, rel)
+}
+
+// Accepts checks if a file (path relative to the walked directory) should
+// be sent
+func (o *options) Accepts(rel string) bool {
+	return len(o.Include) == 0 || utils.MatchAny(o.Include, rel)
+}
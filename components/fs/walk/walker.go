@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileInfo is a description of a file sent in JSON mode
+type fileInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Mode    string    `json:"mode"`
+}
+
+// walker walks a directory passing found files to a callback and errors of
+// unreadable entries to another one (such entries are skipped)
+type walker struct {
+	opts    *options
+	file    func(path string, info os.FileInfo)
+	fail    func(err error)
+	root    string
+	visited map[string]bool
+}
+
+// Walk walks a given directory
+func (w *walker) Walk(root string) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	w.root = root
+	w.visited = map[string]bool{}
+	if !info.IsDir() {
+		// a single file is filtered by its name
+		name := filepath.Base(root)
+		if !w.opts.Skips(name) && w.opts.Accepts(name) {
+			w.file(root, info)
+		}
+		return nil
+	}
+	w.walk(root, 1)
+	return nil
+}
+
+func (w *walker) walk(dir string, depth int) {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if w.visited[real] {
+			// symlink loop
+			return
+		}
+		w.visited[real] = true
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	for _, info := range entries {
+		path := filepath.Join(dir, info.Name())
+		rel, _ := filepath.Rel(w.root, path)
+		if w.opts.Skips(rel) {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 && w.opts.FollowSymlinks {
+			// without following links are sent as they are (even broken ones)
+			target, err := os.Stat(path)
+			if err != nil {
+				w.fail(err)
+				continue
+			}
+			info = target
+		}
+		if !info.IsDir() {
+			if w.opts.Accepts(rel) {
+				w.file(path, info)
+			}
+			continue
+		}
+		if w.opts.MaxDepth == 0 || depth < w.opts.MaxDepth {
+			w.walk(path, depth+1)
+		}
+	}
+}
+
+// describe returns the data to send for a found file
+func describe(path string, info os.FileInfo, asJSON bool) []byte {
+	if !asJSON {
+		return []byte(path)
+	}
+	data, _ := json.Marshal(fileInfo{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Mode:    info.Mode().String(),
+	})
+	return data
+}
@@ -5,9 +5,17 @@ import (
 )
 
 var registryEntry = &library.Entry{
-	Description: "Recursively monitors for changes in the given directory",
-	Elementary:  true,
+	Description: `Monitors for changes of files in the given directory (recursively by default) and sends their paths
+(or JSON descriptions with event, path, size and mtime if enabled in OPTIONS) to the port of the event type.
+Events of the same file within the debounce window are merged into a single one`,
+	Elementary: true,
 	Inports: []library.EntryPort{
+		library.EntryPort{
+			Name:        "OPTIONS",
+			Type:        "json",
+			Description: "Port for optional configuration. E.g. {\"include\": [\"*.go\"], \"exclude\": [\".git\", \"*.swp\"], \"recursive\": true, \"debounce\": \"200ms\", \"json\": true}",
+			Required:    false,
+		},
 		library.EntryPort{
 			Name:        "DIR",
 			Type:        "string",
@@ -20,7 +28,25 @@ var registryEntry = &library.Entry{
 			Name:        "CREATED",
 			Type:        "string",
 			Description: "Created file path",
-			Required:    true,
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "MODIFIED",
+			Type:        "string",
+			Description: "Modified file path",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "DELETED",
+			Type:        "string",
+			Description: "Deleted file path",
+			Required:    false,
+		},
+		library.EntryPort{
+			Name:        "RENAMED",
+			Type:        "string",
+			Description: "Renamed file path (the old one, the new path is sent to CREATED)",
+			Required:    false,
 		},
 		library.EntryPort{
 			Name:        "ERR",
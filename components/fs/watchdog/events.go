@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Event types (also used in JSON output)
+const (
+	eventCreated  = "created"
+	eventModified = "modified"
+	eventDeleted  = "deleted"
+	eventRenamed  = "renamed"
+)
+
+// event is a change of a file to emit
+type event struct {
+	Type    string     `json:"event"`
+	Path    string     `json:"path"`
+	Size    *int64     `json:"size,omitempty"`
+	ModTime *time.Time `json:"mtime,omitempty"`
+
+	last time.Time
+}
+
+// JSON returns the event with the current size & mtime of the file in JSON
+func (e *event) JSON() []byte {
+	if info, err := os.Stat(e.Path); err == nil && !info.IsDir() {
+		size, mtime := info.Size(), info.ModTime()
+		e.Size, e.ModTime = &size, &mtime
+	}
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// debouncer collects events of the same file until no new ones arrive for
+// a given window, merging them into a single event
+type debouncer struct {
+	window  time.Duration
+	pending map[string]*event
+	order   []string
+}
+
+func newDebouncer(window time.Duration) *debouncer {
+	return &debouncer{
+		window:  window,
+		pending: map[string]*event{},
+		order:   []string{},
+	}
+}
+
+// Add records an event merging it with a pending one of the same file
+func (d *debouncer) Add(e *event, now time.Time) {
+	e.last = now
+	prev, ok := d.pending[e.Path]
+	if !ok {
+		d.pending[e.Path] = e
+		d.order = append(d.order, e.Path)
+		return
+	}
+	switch {
+	case prev.Type == eventCreated && e.Type == eventModified:
+		// still a new file
+		prev.last = now
+	case prev.Type == eventCreated && e.Type == eventDeleted:
+		// a temporary file: nothing happened
+		delete(d.pending, e.Path)
+	case (prev.Type == eventDeleted || prev.Type == eventRenamed) && e.Type == eventCreated:
+		// replaced by another file (e.g. atomic save of an editor)
+		prev.Type = eventModified
+		prev.last = now
+	default:
+		d.pending[e.Path] = e
+	}
+}
+
+// Ready returns events which files had no changes within the window
+func (d *debouncer) Ready(now time.Time) []*event {
+	ready := []*event{}
+	order := []string{}
+	seen := map[string]bool{}
+	for _, path := range d.order {
+		e, ok := d.pending[path]
+		if !ok || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if now.Sub(e.last) >= d.window {
+			ready = append(ready, e)
+			delete(d.pending, path)
+			continue
+		}
+		order = append(order, path)
+	}
+	d.order = order
+	return ready
+}
@@ -1,14 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/cascades-fbp/cascades/components/utils"
 	"github.com/cascades-fbp/cascades/runtime"
@@ -17,19 +17,23 @@ import (
 )
 
 var (
-	// Flafs
-	inputEndpoint  = flag.String("port.dir", "", "Component's input port endpoint")
-	outputEndpoint = flag.String("port.created", "", "Component's output port endpoint")
-	errorEndpoint  = flag.String("port.err", "", "Component's error port endpoint")
-	jsonFlag       = flag.Bool("json", false, "Print component documentation in JSON")
-	debug          = flag.Bool("debug", false, "Enable debug mode")
+	// Flags
+	optionsEndpoint  = flag.String("port.options", "", "Component's options port endpoint")
+	inputEndpoint    = flag.String("port.dir", "", "Component's input port endpoint")
+	createdEndpoint  = flag.String("port.created", "", "Component's output port endpoint for created files")
+	modifiedEndpoint = flag.String("port.modified", "", "Component's output port endpoint for modified files")
+	deletedEndpoint  = flag.String("port.deleted", "", "Component's output port endpoint for deleted files")
+	renamedEndpoint  = flag.String("port.renamed", "", "Component's output port endpoint for renamed files")
+	errorEndpoint    = flag.String("port.err", "", "Component's error port endpoint")
+	jsonFlag         = flag.Bool("json", false, "Print component documentation in JSON")
+	debug            = flag.Bool("debug", false, "Enable debug mode")
 
 	// Internal
-	inPort, outPort, errPort *zmq.Socket
-	outCh, errCh             chan bool
-	exitCh                   chan os.Signal
-	ip                       [][]byte
-	err                      error
+	optionsPort, inPort, errPort *zmq.Socket
+	eventPorts                   map[string]*zmq.Socket
+	outCh                        chan bool
+	exitCh                       chan os.Signal
+	err                          error
 )
 
 func main() {
@@ -41,18 +45,12 @@ func main() {
 		os.Exit(0)
 	}
 
-	log.SetFlags(0)
-	if *debug {
-		log.SetOutput(os.Stdout)
-	} else {
-		log.SetOutput(ioutil.Discard)
-	}
+	utils.SetupLogging(*debug)
 
 	validateArgs()
 
 	// Communication channels
 	outCh = make(chan bool)
-	errCh = make(chan bool)
 	exitCh = make(chan os.Signal, 1)
 
 	// Start the communication & processing logic
@@ -78,69 +76,129 @@ func mainLoop() {
 	openPorts()
 	defer closePorts()
 
-	// Process events
 	go func() {
-		//  Socket to send messages to task sink
-		outPort, err = utils.CreateOutputPort("fs/watchdog.out", *outputEndpoint, errCh)
-		if err != nil {
-			exitCh <- syscall.SIGTERM
-			return
-		}
 		for {
 			select {
-			case ev := <-watcher.Event:
-				log.Println("Event:", ev)
-				if ev.IsCreate() {
-					if isDir(ev.Name) {
-						err = filepath.Walk(ev.Name, func(path string, info os.FileInfo, err error) error {
-							if err != nil {
-								return err
-							}
-							if info.IsDir() {
-								// we need to watch every subdirectory explicitely
-								watcher.Watch(path)
-								log.Println("Added to watch:", path)
-							} else {
-								// Consider every file found in the created directory as just created
-								outPort.SendMessage(runtime.NewPacket([]byte(path)))
-							}
-							return nil
-						})
-						if err != nil {
-							log.Println("Error walking directory:", err.Error())
-						}
-					} else {
-						outPort.SendMessage(runtime.NewPacket([]byte(ev.Name)))
-					}
-				} else if ev.IsDelete() && isDir(ev.Name) {
-					watcher.RemoveWatch(ev.Name)
-					log.Println("Removed from watch:", ev.Name)
+			case v := <-outCh:
+				if !v {
+					log.Println("Output port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
 				}
-			case err := <-watcher.Error:
-				log.Println("Error:", err)
 			}
 		}
 	}()
 
+	opts := defaultOptions()
+	if optionsPort != nil {
+		log.Println("Waiting for options...")
+		for {
+			ip, err := optionsPort.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				log.Println("Invalid IP:", ip)
+				continue
+			}
+			opts = defaultOptions()
+			if err = json.Unmarshal(ip[1], opts); err == nil {
+				err = opts.Validate()
+			}
+			if err != nil {
+				log.Println("ERROR: Invalid options:", err.Error())
+				continue
+			}
+			log.Printf("Using options: %#v", opts)
+			break
+		}
+		optionsPort.Close()
+	}
+
+	dirs := newTree(watcher, opts)
+	events := make(chan *event)
+	errors := make(chan string)
+
+	// Send events (all ports are used by this goroutine only)
 	go func() {
+		pending := newDebouncer(opts.debounce)
+		tick := time.NewTicker(time.Hour)
+		if opts.debounce > 0 {
+			tick = time.NewTicker(opts.debounce / 2)
+		}
 		for {
 			select {
-			case v := <-outCh:
-				if !v {
-					log.Println("CREATED port is closed. Interrupting execution")
-					exitCh <- syscall.SIGTERM
-					break
+			case e := <-events:
+				if opts.debounce == 0 {
+					send(e, opts)
+					continue
 				}
-			case v := <-errCh:
-				if !v {
-					log.Println("ERR port is closed. Interrupting execution")
-					exitCh <- syscall.SIGTERM
-					break
+				pending.Add(e, time.Now())
+			case <-tick.C:
+				for _, e := range pending.Ready(time.Now()) {
+					send(e, opts)
+				}
+			case msg := <-errors:
+				if errPort != nil {
+					errPort.SendMessage(runtime.NewPacket([]byte(msg)))
 				}
 			}
 		}
 	}()
 
+	// Process file system events
+	go func() {
+		emit := func(t, path string) {
+			if opts.Accepts(dirs.Rel(path)) {
+				events <- &event{Type: t, Path: path}
+			}
+		}
+		for {
+			select {
+			case ev := <-watcher.Event:
+				log.Println("Event:", ev)
+				switch {
+				case ev.IsCreate():
+					if !isDir(ev.Name) {
+						emit(eventCreated, ev.Name)
+						continue
+					}
+					if !opts.Recursive || opts.Excluded(dirs.Rel(ev.Name)) {
+						continue
+					}
+					// Consider every file found in the created directory as just created
+					files, err := dirs.AddDir(ev.Name)
+					if err != nil {
+						log.Println("Error walking directory:", err.Error())
+						errors <- err.Error()
+					}
+					for _, f := range files {
+						emit(eventCreated, f)
+					}
+				case ev.IsDelete():
+					if dirs.Remove(ev.Name) {
+						log.Println("Removed from watch:", ev.Name)
+						continue
+					}
+					emit(eventDeleted, ev.Name)
+				case ev.IsRename():
+					if dirs.Remove(ev.Name) {
+						log.Println("Removed from watch:", ev.Name)
+						continue
+					}
+					emit(eventRenamed, ev.Name)
+				case ev.IsModify():
+					if !isDir(ev.Name) {
+						emit(eventModified, ev.Name)
+					}
+				}
+			case err := <-watcher.Error:
+				log.Println("Error:", err)
+				errors <- err.Error()
+			}
+		}
+	}()
+
 	// Main loop
 	log.Println("Started")
 	for {
@@ -148,31 +206,31 @@ func mainLoop() {
 		if err != nil {
 			continue
 		}
-		if !runtime.IsValidIP(ip) {
+		if !runtime.IsValidIP(ip) || !runtime.IsPacket(ip) {
 			continue
 		}
 
 		dir := string(ip[1])
-		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if info.IsDir() {
-				watcher.Watch(path)
-				log.Println("Added to watch:", path)
-			}
-			return nil
-		})
-		if err != nil {
-			log.Printf("ERROR opening file %s: %s", dir, err.Error())
-			if errPort != nil {
-				errPort.SendMessage(runtime.NewPacket([]byte(err.Error())))
-			}
-			continue
+		if err = dirs.AddRoot(dir); err != nil {
+			log.Printf("ERROR watching directory %s: %s", dir, err.Error())
+			errors <- err.Error()
 		}
 	}
 }
 
+// send sends an event to the port of its type (if connected)
+func send(e *event, opts *options) {
+	port := eventPorts[e.Type]
+	if port == nil {
+		return
+	}
+	data := []byte(e.Path)
+	if opts.JSON {
+		data = e.JSON()
+	}
+	port.SendMessage(runtime.NewPacket(data))
+}
+
 func isDir(path string) bool {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -187,7 +245,7 @@ func validateArgs() {
 		flag.Usage()
 		os.Exit(1)
 	}
-	if *outputEndpoint == "" {
+	if *createdEndpoint == "" && *modifiedEndpoint == "" && *deletedEndpoint == "" && *renamedEndpoint == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -195,11 +253,31 @@ func validateArgs() {
 
 // openPorts create ZMQ sockets and start socket monitoring loops
 func openPorts() {
-	inPort, err = utils.CreateInputPort("fs/watchdog.in", *inputEndpoint, nil)
+	if *optionsEndpoint != "" {
+		optionsPort, err = utils.CreateInputPort("fs/watchdog.options", *optionsEndpoint, nil)
+		utils.AssertError(err)
+	}
+
+	inPort, err = utils.CreateInputPort("fs/watchdog.dir", *inputEndpoint, nil)
 	utils.AssertError(err)
 
+	eventPorts = map[string]*zmq.Socket{}
+	endpoints := map[string]string{
+		eventCreated:  *createdEndpoint,
+		eventModified: *modifiedEndpoint,
+		eventDeleted:  *deletedEndpoint,
+		eventRenamed:  *renamedEndpoint,
+	}
+	for t, endpoint := range endpoints {
+		if endpoint == "" {
+			continue
+		}
+		eventPorts[t], err = utils.CreateOutputPort("fs/watchdog."+t, endpoint, outCh)
+		utils.AssertError(err)
+	}
+
 	if *errorEndpoint != "" {
-		errPort, err = utils.CreateOutputPort("fs/watchdog.err", *errorEndpoint, errCh)
+		errPort, err = utils.CreateOutputPort("fs/watchdog.err", *errorEndpoint, outCh)
 		utils.AssertError(err)
 	}
 }
@@ -208,8 +286,8 @@ func openPorts() {
 func closePorts() {
 	log.Println("Closing ports...")
 	inPort.Close()
-	if outPort != nil {
-		outPort.Close()
+	for _, port := range eventPorts {
+		port.Close()
 	}
 	if errPort != nil {
 		errPort.Close()
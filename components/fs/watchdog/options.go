@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+)
+
+// minDebounce is the shortest debounce interval (events are checked every
+// half of the interval)
+const minDebounce = 10 * time.Millisecond
+
+type options struct {
+	Include   []string `json:"include"`
+	Exclude   []string `json:"exclude"`
+	Recursive bool     `json:"recursive"`
+	Debounce  string   `json:"debounce"`
+	JSON      bool     `json:"json"`
+
+	debounce time.Duration
+}
+
+// defaultOptions are used when OPTIONS port is not connected
+func defaultOptions() *options {
+	return &options{Recursive: true}
+}
+
+func (o *options) Validate() error {
+	if err := utils.CheckPatterns(append(append([]string{}, o.Include...), o.Exclude...)); err != nil {
+		return err
+	}
+	if o.Debounce != "" {
+		d, err := time.ParseDuration(o.Debounce)
+		if err != nil {
+			return fmt.Errorf("Invalid debounce %s: %s", o.Debounce, err.Error())
+		}
+		if d != 0 && d < minDebounce {
+			return fmt.Errorf("Invalid debounce %s: should be 0 (disabled) or at least %s", o.Debounce, minDebounce)
+		}
+		o.debounce = d
+	}
+	return nil
+}
+
+// Excluded checks if a path (relative to the watched directory) matches
+// any of the exclude patterns
+func (o *options) Excluded(rel string) bool {
+	return utils.MatchAny(o.Exclude, rel)
+}
+
+// Accepts checks if events of a file (path relative to the watched
+// directory) should be emitted
+func (o *options) Accepts(rel string) bool {
+	if o.Excluded(rel) {
+		return false
+	}
+	return len(o.Include) == 0 || utils.MatchAny(o.Include, rel)
+}
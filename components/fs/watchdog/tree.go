@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/howeyc/fsnotify"
+)
+
+// tree keeps track of the watched directories
+type tree struct {
+	watcher *fsnotify.Watcher
+	opts    *options
+	mutex   sync.Mutex
+	roots   []string
+	watched map[string]bool
+}
+
+func newTree(watcher *fsnotify.Watcher, opts *options) *tree {
+	return &tree{
+		watcher: watcher,
+		opts:    opts,
+		roots:   []string{},
+		watched: map[string]bool{},
+	}
+}
+
+// AddRoot starts watching a directory (with subdirectories if recursive)
+func (t *tree) AddRoot(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	t.mutex.Lock()
+	t.roots = append(t.roots, filepath.Clean(dir))
+	t.mutex.Unlock()
+	if !t.opts.Recursive {
+		return t.watch(dir)
+	}
+	_, err = t.AddDir(dir)
+	return err
+}
+
+// AddDir watches a directory with its subdirectories (skipping excluded
+// ones) and returns the files found in it
+func (t *tree) AddDir(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && t.opts.Excluded(t.Rel(path)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return t.watch(path)
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
+
+// Remove stops watching a directory and returns true if it was watched
+func (t *tree) Remove(path string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.watched[path] {
+		return false
+	}
+	delete(t.watched, path)
+	t.watcher.RemoveWatch(path)
+	return true
+}
+
+// Rel returns a path relative to the watched directory containing it
+func (t *tree) Rel(path string) string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, root := range t.roots {
+		rel, err := filepath.Rel(root, path)
+		// names starting with ".." (e.g. "..cache") are inside of the root
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel
+		}
+	}
+	return filepath.Base(path)
+}
+
+func (t *tree) watch(dir string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.watched[dir] {
+		return nil
+	}
+	if err := t.watcher.Watch(dir); err != nil {
+		return err
+	}
+	t.watched[dir] = true
+	log.Println("Added to watch:", dir)
+	return nil
+}
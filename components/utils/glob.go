@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CheckPatterns makes sure all given glob patterns are valid
+func CheckPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("Invalid pattern %s: %s", p, err.Error())
+		}
+	}
+	return nil
+}
+
+// MatchAny checks if a path (relative to a walked or watched directory)
+// matches any of the glob patterns. Patterns with a path separator are
+// matched against the whole path and the rest against the base name
+func MatchAny(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		target := filepath.Base(rel)
+		if strings.Contains(p, "/") {
+			target = rel
+		}
+		if ok, _ := filepath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	logging "github.com/cascades-fbp/cascades/log"
+)
+
+// logLevel is the minimal level of messages written by the component
+var logLevel = logging.LevelInfo
+
+// SetupLogging configures logging of a component. The level is taken from
+// the environment set by the runtime (debug flag enforces debug level).
+// Output of the standard logger is treated as debug output: it is tagged
+// and written to stdout only when debug level is enabled
+func SetupLogging(debug bool) {
+	logLevel = logging.LevelInfo
+	if value := os.Getenv(logging.LevelEnv); value != "" {
+		if level, err := logging.ParseLevel(value); err == nil {
+			logLevel = level
+		}
+	}
+	if debug {
+		logLevel = logging.LevelDebug
+	}
+
+	log.SetFlags(0)
+	if logLevel == logging.LevelDebug {
+		log.SetPrefix(logging.LevelDebug.Tag())
+		log.SetOutput(os.Stdout)
+	} else {
+		log.SetOutput(ioutil.Discard)
+	}
+}
+
+// Debugf writes a debug message
+func Debugf(format string, args ...interface{}) {
+	logf(os.Stdout, logging.LevelDebug, format, args...)
+}
+
+// Infof writes an informational message
+func Infof(format string, args ...interface{}) {
+	logf(os.Stdout, logging.LevelInfo, format, args...)
+}
+
+// Warnf writes a warning
+func Warnf(format string, args ...interface{}) {
+	logf(os.Stdout, logging.LevelWarn, format, args...)
+}
+
+// Errorf writes an error message to stderr
+func Errorf(format string, args ...interface{}) {
+	logf(os.Stderr, logging.LevelError, format, args...)
+}
+
+func logf(w io.Writer, level logging.Level, format string, args ...interface{}) {
+	if level < logLevel {
+		return
+	}
+	fmt.Fprintln(w, level.Tag()+fmt.Sprintf(format, args...))
+}
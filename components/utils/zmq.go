@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/cascades-fbp/cascades/runtime"
 	zmq "github.com/pebbe/zmq4"
 )
 
@@ -42,25 +43,9 @@ func CreateInputPort(name string, endpoint string, monitCh chan<- bool) (socket
 		return nil, err
 	}
 
-	go func() {
-		c := 0
-		for e := range ch {
-			if e == zmq.EVENT_ACCEPTED {
-				c++
-				if c == 1 {
-					monitCh <- true
-				}
-			} else if e == zmq.EVENT_CLOSED || e == zmq.EVENT_DISCONNECTED {
-				c--
-				if c == 0 {
-					monitCh <- false
-				}
-			}
-			if c < 0 {
-				c = 0
-			}
-		}
-	}()
+	go monitorConnections(ch, monitCh, func(e zmq.Event) bool {
+		return e == zmq.EVENT_ACCEPTED
+	})
 
 	return socket, nil
 }
@@ -84,27 +69,55 @@ func CreateOutputPort(name string, endpoint string, monitCh chan<- bool) (socket
 		return nil, err
 	}
 
-	go func() {
-		c := 0
-		for e := range ch {
-			if e == zmq.EVENT_ACCEPTED || e == zmq.EVENT_CONNECTED {
+	go monitorConnections(ch, monitCh, func(e zmq.Event) bool {
+		return e == zmq.EVENT_ACCEPTED || e == zmq.EVENT_CONNECTED
+	})
+
+	return socket, nil
+}
+
+// monitorConnections counts connected peers of a socket using its monitoring
+// events and reports to a given channel when the first peer connects (true) and
+// the last one disconnects (false). If the runtime provides a reconnect grace
+// period the disconnect is reported only if no peer reconnects within it
+func monitorConnections(ch <-chan zmq.Event, monitCh chan<- bool, isConnected func(zmq.Event) bool) {
+	grace, _ := time.ParseDuration(os.Getenv(runtime.ReconnectGraceEnv))
+	var closed <-chan time.Time
+	c := 0
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if isConnected(e) {
 				c++
 				if c == 1 {
-					monitCh <- true
+					if closed != nil {
+						// peer reconnected within the grace period
+						closed = nil
+					} else {
+						monitCh <- true
+					}
 				}
 			} else if e == zmq.EVENT_CLOSED || e == zmq.EVENT_DISCONNECTED {
 				c--
 				if c == 0 {
-					monitCh <- false
+					if grace > 0 {
+						closed = time.After(grace)
+					} else {
+						monitCh <- false
+					}
 				}
 			}
 			if c < 0 {
 				c = 0
 			}
+		case <-closed:
+			closed = nil
+			monitCh <- false
 		}
-	}()
-
-	return socket, nil
+	}
 }
 
 //
@@ -0,0 +1,206 @@
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/cascades-fbp/cascades/graph"
+	"github.com/cascades-fbp/cascades/library"
+)
+
+// Format of the generated documentation
+type Format string
+
+const (
+	// FormatMarkdown generates .md pages (subgraphs are rendered as Mermaid code blocks)
+	FormatMarkdown Format = "markdown"
+	// FormatHTML generates .html pages (subgraphs are rendered with Mermaid in a browser)
+	FormatHTML Format = "html"
+)
+
+// Generator generates documentation pages for all entries of a library
+type Generator struct {
+	Registrar library.Registrar
+	Resolver  *library.Resolver
+	Format    Format
+	Title     string
+
+	// Warn (if set) is called with a message about every entry which could
+	// not be documented (such entries are skipped)
+	Warn func(message string)
+}
+
+// page is the data passed to the entry page template
+type page struct {
+	Title     string
+	Entry     library.Entry
+	Key       string
+	Index     string
+	Versions  []link
+	Processes []process
+	Diagram   string
+}
+
+type link struct {
+	Name string
+	Page string
+}
+
+type process struct {
+	Name      string
+	Component string
+	Page      string
+}
+
+// index is the data passed to the index page template
+type index struct {
+	Title   string
+	Entries []indexEntry
+}
+
+type indexEntry struct {
+	Key         string
+	Page        string
+	Description string
+	Elementary  bool
+}
+
+// Generate writes a page per library entry and an index page to a given
+// directory. Entries which could not be documented are skipped
+func (g *Generator) Generate(dir string) error {
+	if g.Format != FormatMarkdown && g.Format != FormatHTML {
+		return fmt.Errorf("Unsupported format %s (should be markdown or html)", g.Format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	entries := g.Registrar.List()
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	idx := index{
+		Title:   g.Title,
+		Entries: []indexEntry{},
+	}
+	for _, k := range keys {
+		e := entries[k]
+		p, err := g.entryPage(k, e, entries)
+		if err != nil {
+			if g.Warn != nil {
+				g.Warn(err.Error())
+			}
+			continue
+		}
+		if err = g.write(filepath.Join(dir, g.pageName(k)), "entry", p); err != nil {
+			return err
+		}
+		idx.Entries = append(idx.Entries, indexEntry{
+			Key:         k,
+			Page:        g.pageName(k),
+			Description: e.Description,
+			Elementary:  e.Elementary,
+		})
+	}
+	return g.write(filepath.Join(dir, g.pageName("index")), "index", idx)
+}
+
+// entryPage collects the data for a page of a single entry
+func (g *Generator) entryPage(key string, e library.Entry, entries map[string]library.Entry) (*page, error) {
+	p := &page{
+		Title:     g.Title,
+		Entry:     e,
+		Key:       key,
+		Index:     g.pageName("index"),
+		Versions:  []link{},
+		Processes: []process{},
+	}
+	for k, other := range entries {
+		if other.Name == e.Name && k != key {
+			p.Versions = append(p.Versions, link{Name: k, Page: g.pageName(k)})
+		}
+	}
+	sort.Slice(p.Versions, func(i, j int) bool { return p.Versions[i].Name < p.Versions[j].Name })
+
+	if e.Elementary {
+		return p, nil
+	}
+
+	// composite components: list processes and render the subgraph
+	path, err := g.Resolver.Resolve(e.Executable)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to document %s: %s", key, err.Error())
+	}
+	sub, err := graph.ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to document %s: %s", key, err.Error())
+	}
+	names := []string{}
+	for name := range sub.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pr := process{Name: name, Component: sub.Processes[name].Component}
+		if ref, err := sub.Processes[name].ComponentRef(); err == nil {
+			if c, err := g.Registrar.Get(ref); err == nil {
+				pr.Page = g.pageName(c.Key())
+			}
+		}
+		p.Processes = append(p.Processes, pr)
+	}
+	var diagram bytes.Buffer
+	if err = graph.NewDiagram(sub).WriteMermaid(&diagram); err != nil {
+		return nil, fmt.Errorf("Failed to render %s: %s", key, err.Error())
+	}
+	p.Diagram = diagram.String()
+	return p, nil
+}
+
+// pageName returns a file name of the page for a given entry key
+func (g *Generator) pageName(key string) string {
+	ext := ".md"
+	if g.Format == FormatHTML {
+		ext = ".html"
+	}
+	return strings.NewReplacer("/", "_", "@", "-").Replace(key) + ext
+}
+
+// write executes a named template into a file
+func (g *Generator) write(path, name string, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.execute(f, name, data)
+}
+
+func (g *Generator) execute(w io.Writer, name string, data interface{}) error {
+	funcs := map[string]interface{}{
+		"upper": strings.ToUpper,
+		"cell":  markdownCell,
+	}
+	if g.Format == FormatHTML {
+		t := htmltemplate.Must(htmltemplate.New("docs").Funcs(funcs).Parse(htmlTemplates))
+		return t.ExecuteTemplate(w, name, data)
+	}
+	t := texttemplate.Must(texttemplate.New("docs").Funcs(funcs).Parse(markdownTemplates))
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// markdownCell escapes a text to be used inside of a markdown table cell
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
@@ -0,0 +1,106 @@
+package docs
+
+const markdownTemplates = `
+{{define "ports"}}
+| Port | Type | Required | Addressable | Description |
+|------|------|----------|-------------|-------------|
+{{range .}}| {{upper .Name}} | {{.Type}} | {{.Required}} | {{.Addressable}} | {{cell .Description}} |
+{{end}}{{end}}
+
+{{define "entry"}}# {{.Key}}
+
+{{.Entry.Description}}
+
+- **Type:** {{if .Entry.Elementary}}elementary{{else}}composite{{end}}
+{{if .Entry.Version}}- **Version:** {{.Entry.Version}}
+{{end}}- **Executable:** ` + "`{{.Entry.Executable}}`" + `
+{{if .Versions}}- **Other versions:** {{range $i, $v := .Versions}}{{if $i}}, {{end}}[{{$v.Name}}]({{$v.Page}}){{end}}
+{{end}}
+## Inports
+{{if .Entry.Inports}}{{template "ports" .Entry.Inports}}{{else}}
+None
+{{end}}
+## Outports
+{{if .Entry.Outports}}{{template "ports" .Entry.Outports}}{{else}}
+None
+{{end}}{{if not .Entry.Elementary}}
+## Processes
+
+| Process | Component |
+|---------|-----------|
+{{range .Processes}}| {{.Name}} | {{if .Page}}[{{.Component}}]({{.Page}}){{else}}{{.Component}}{{end}} |
+{{end}}
+## Graph
+
+` + "```mermaid" + `
+{{.Diagram}}` + "```" + `
+{{end}}
+[Back to index]({{.Index}})
+{{end}}
+
+{{define "index"}}# {{.Title}}
+
+| Component | Type | Description |
+|-----------|------|-------------|
+{{range .Entries}}| [{{.Key}}]({{.Page}}) | {{if .Elementary}}elementary{{else}}composite{{end}} | {{cell .Description}} |
+{{end}}{{end}}
+`
+
+const htmlTemplates = `
+{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+code { background: #f4f4f4; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "footer"}}</body>
+</html>
+{{end}}
+
+{{define "ports"}}<table>
+<tr><th>Port</th><th>Type</th><th>Required</th><th>Addressable</th><th>Description</th></tr>
+{{range .}}<tr><td>{{upper .Name}}</td><td>{{.Type}}</td><td>{{.Required}}</td><td>{{.Addressable}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{define "entry"}}{{template "header" .Key}}<h1>{{.Key}}</h1>
+<p>{{.Entry.Description}}</p>
+<ul>
+<li><b>Type:</b> {{if .Entry.Elementary}}elementary{{else}}composite{{end}}</li>
+{{if .Entry.Version}}<li><b>Version:</b> {{.Entry.Version}}</li>
+{{end}}<li><b>Executable:</b> <code>{{.Entry.Executable}}</code></li>
+{{if .Versions}}<li><b>Other versions:</b> {{range $i, $v := .Versions}}{{if $i}}, {{end}}<a href="{{$v.Page}}">{{$v.Name}}</a>{{end}}</li>
+{{end}}</ul>
+<h2>Inports</h2>
+{{if .Entry.Inports}}{{template "ports" .Entry.Inports}}{{else}}<p>None</p>
+{{end}}<h2>Outports</h2>
+{{if .Entry.Outports}}{{template "ports" .Entry.Outports}}{{else}}<p>None</p>
+{{end}}{{if not .Entry.Elementary}}<h2>Processes</h2>
+<table>
+<tr><th>Process</th><th>Component</th></tr>
+{{range .Processes}}<tr><td>{{.Name}}</td><td>{{if .Page}}<a href="{{.Page}}">{{.Component}}</a>{{else}}{{.Component}}{{end}}</td></tr>
+{{end}}</table>
+<h2>Graph</h2>
+<pre class="mermaid">
+{{.Diagram}}</pre>
+<script src="https://cdn.jsdelivr.net/npm/mermaid/dist/mermaid.min.js"></script>
+<script>mermaid.initialize({startOnLoad: true});</script>
+{{end}}<p><a href="{{.Index}}">Back to index</a></p>
+{{template "footer"}}{{end}}
+
+{{define "index"}}{{template "header" .Title}}<h1>{{.Title}}</h1>
+<table>
+<tr><th>Component</th><th>Type</th><th>Description</th></tr>
+{{range .Entries}}<tr><td><a href="{{.Page}}">{{.Key}}</a></td><td>{{if .Elementary}}elementary{{else}}composite{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{template "footer"}}{{end}}
+`
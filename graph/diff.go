@@ -0,0 +1,520 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeType describes the kind of change of a graph element
+type ChangeType string
+
+const (
+	// Added element exists only in the new graph
+	Added ChangeType = "added"
+	// Removed element exists only in the old graph
+	Removed ChangeType = "removed"
+	// Changed element exists in both graphs, but differs
+	Changed ChangeType = "changed"
+)
+
+// PropertyChange describes a change of a graph property
+type PropertyChange struct {
+	Type ChangeType `json:"type"`
+	Name string     `json:"name"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// ProcessChange describes a change of a process (component or metadata)
+type ProcessChange struct {
+	Type ChangeType `json:"type"`
+	Name string     `json:"name"`
+	Old  *Process   `json:"old,omitempty"`
+	New  *Process   `json:"new,omitempty"`
+}
+
+// ConnectionChange describes a change of a connection between processes
+type ConnectionChange struct {
+	Type ChangeType  `json:"type"`
+	Old  *Connection `json:"old,omitempty"`
+	New  *Connection `json:"new,omitempty"`
+}
+
+// InitialChange describes a change of IIPs sent to a port
+type InitialChange struct {
+	Type   ChangeType `json:"type"`
+	Target *Endpoint  `json:"tgt"`
+	Old    []string   `json:"old,omitempty"`
+	New    []string   `json:"new,omitempty"`
+}
+
+// ExportChange describes a change of an exported port
+type ExportChange struct {
+	Type ChangeType `json:"type"`
+	Name string     `json:"name"`
+	Old  *Export    `json:"old,omitempty"`
+	New  *Export    `json:"new,omitempty"`
+}
+
+// Diff is a structural difference between two graphs independent of
+// their format and ordering of the elements
+type Diff struct {
+	Properties  []PropertyChange   `json:"properties"`
+	Processes   []ProcessChange    `json:"processes"`
+	Connections []ConnectionChange `json:"connections"`
+	Initials    []InitialChange    `json:"iips"`
+	Inports     []ExportChange     `json:"inports"`
+	Outports    []ExportChange     `json:"outports"`
+}
+
+// Compare returns a structural difference between two graphs
+func Compare(from, to *Description) *Diff {
+	d := &Diff{
+		Properties:  []PropertyChange{},
+		Processes:   []ProcessChange{},
+		Connections: []ConnectionChange{},
+		Initials:    []InitialChange{},
+		Inports:     []ExportChange{},
+		Outports:    []ExportChange{},
+	}
+	a, b := newElements(from), newElements(to)
+	for _, key := range unionKeys(a, b) {
+		oldEl, inOld := a[key]
+		newEl, inNew := b[key]
+		var t ChangeType
+		switch {
+		case inOld && inNew && oldEl.fingerprint == newEl.fingerprint:
+			continue
+		case inOld && inNew:
+			t = Changed
+		case inNew:
+			t = Added
+		default:
+			t = Removed
+		}
+		d.add(t, key, oldEl, newEl)
+	}
+	return d
+}
+
+// Empty returns true if there are no differences
+func (d *Diff) Empty() bool {
+	return len(d.Properties) == 0 && len(d.Processes) == 0 && len(d.Connections) == 0 &&
+		len(d.Initials) == 0 && len(d.Inports) == 0 && len(d.Outports) == 0
+}
+
+// String returns human-readable representation of the difference
+// (+ for added, - for removed and ~ for changed elements)
+func (d *Diff) String() string {
+	var b bytes.Buffer
+	for _, c := range d.Properties {
+		fmt.Fprintf(&b, "%s property %s: %s\n", changeMark(c.Type), c.Name, changeValues(c.Type, c.Old, c.New))
+	}
+	for _, c := range d.Processes {
+		var old, updated string
+		if c.Old != nil {
+			old = c.Old.Component + metadataString(c.Old.Metadata)
+		}
+		if c.New != nil {
+			updated = c.New.Component + metadataString(c.New.Metadata)
+		}
+		fmt.Fprintf(&b, "%s process %s: %s\n", changeMark(c.Type), c.Name, changeValues(c.Type, old, updated))
+	}
+	for _, c := range d.Connections {
+		conn := c.New
+		if conn == nil {
+			conn = c.Old
+		}
+		fmt.Fprintf(&b, "%s connection %s\n", changeMark(c.Type), conn.String())
+	}
+	for _, c := range d.Initials {
+		fmt.Fprintf(&b, "%s iip %s: %s\n", changeMark(c.Type), c.Target.String(false), changeValues(c.Type, quoteAll(c.Old), quoteAll(c.New)))
+	}
+	for _, c := range d.Inports {
+		fmt.Fprintf(&b, "%s inport %s\n", changeMark(c.Type), exportChangeString(c))
+	}
+	for _, c := range d.Outports {
+		fmt.Fprintf(&b, "%s outport %s\n", changeMark(c.Type), exportChangeString(c))
+	}
+	return b.String()
+}
+
+// ChangedProcesses returns names of the processes affected by the difference
+// (added, removed or changed processes as well as targets of changed IIPs)
+func (d *Diff) ChangedProcesses() []string {
+	seen := map[string]bool{}
+	for _, c := range d.Processes {
+		seen[c.Name] = true
+	}
+	for _, c := range d.Initials {
+		seen[c.Target.Process] = true
+	}
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *Diff) add(t ChangeType, key string, old, updated element) {
+	kind, name := splitElementKey(key)
+	switch kind {
+	case kindProperty:
+		c := PropertyChange{Type: t, Name: name}
+		if old.value != nil {
+			c.Old = old.value.(string)
+		}
+		if updated.value != nil {
+			c.New = updated.value.(string)
+		}
+		d.Properties = append(d.Properties, c)
+	case kindProcess:
+		c := ProcessChange{Type: t, Name: name}
+		if old.value != nil {
+			p := old.value.(Process)
+			c.Old = &p
+		}
+		if updated.value != nil {
+			p := updated.value.(Process)
+			c.New = &p
+		}
+		d.Processes = append(d.Processes, c)
+	case kindConnection:
+		c := ConnectionChange{Type: t}
+		if old.value != nil {
+			conn := old.value.(Connection)
+			c.Old = &conn
+		}
+		if updated.value != nil {
+			conn := updated.value.(Connection)
+			c.New = &conn
+		}
+		d.Connections = append(d.Connections, c)
+	case kindInitial:
+		c := InitialChange{Type: t}
+		if old.value != nil {
+			c.Target = old.target
+			c.Old = old.value.([]string)
+		}
+		if updated.value != nil {
+			c.Target = updated.target
+			c.New = updated.value.([]string)
+		}
+		d.Initials = append(d.Initials, c)
+	case kindInport, kindOutport:
+		c := ExportChange{Type: t, Name: name}
+		if old.value != nil {
+			e := old.value.(Export)
+			c.Old = &e
+		}
+		if updated.value != nil {
+			e := updated.value.(Export)
+			c.New = &e
+		}
+		if kind == kindInport {
+			d.Inports = append(d.Inports, c)
+		} else {
+			d.Outports = append(d.Outports, c)
+		}
+	}
+}
+
+// Conflict describes an element changed differently in both merged graphs
+type Conflict struct {
+	Key    string      `json:"key"`
+	Base   interface{} `json:"base"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("conflict in %s: base=%s ours=%s theirs=%s", c.Key, jsonString(c.Base), jsonString(c.Ours), jsonString(c.Theirs))
+}
+
+// Merge performs a three-way merge of two graphs derived from a common base.
+// Elements changed in only one of the graphs are taken from it, in case of
+// conflicting changes our version is used and the conflict is reported.
+// Connections, IIPs and exports referring to a process removed in the other
+// graph are dropped and reported as conflicts as well
+func Merge(base, ours, theirs *Description) (*Description, []Conflict) {
+	b, o, t := newElements(base), newElements(ours), newElements(theirs)
+	merged := elements{}
+	conflicts := []Conflict{}
+	for _, key := range unionKeys(b, o, t) {
+		be, inBase := b[key]
+		oe, inOurs := o[key]
+		te, inTheirs := t[key]
+		switch {
+		case inOurs == inTheirs && oe.fingerprint == te.fingerprint:
+			// same in both (or removed in both)
+			if inOurs {
+				merged[key] = oe
+			}
+		case inBase == inOurs && be.fingerprint == oe.fingerprint:
+			// changed in theirs only
+			if inTheirs {
+				merged[key] = te
+			}
+		case inBase == inTheirs && be.fingerprint == te.fingerprint:
+			// changed in ours only
+			if inOurs {
+				merged[key] = oe
+			}
+		default:
+			conflicts = append(conflicts, Conflict{Key: key, Base: be.value, Ours: oe.value, Theirs: te.value})
+			if inOurs {
+				merged[key] = oe
+			}
+		}
+	}
+
+	// a process removed in one graph may still be referenced by connections,
+	// IIPs or exports added in the other one: such elements are dropped
+	for _, key := range sortedKeys(merged) {
+		for _, process := range merged[key].processes() {
+			if _, ok := merged[kindProcess+":"+process]; !ok {
+				conflicts = append(conflicts, Conflict{Key: key, Base: b[key].value, Ours: o[key].value, Theirs: t[key].value})
+				delete(merged, key)
+				break
+			}
+		}
+	}
+	return merged.description(ours, theirs), conflicts
+}
+
+//
+// Internal representation of the graph as a set of keyed elements
+//
+
+const (
+	kindProperty   = "property"
+	kindProcess    = "process"
+	kindConnection = "connection"
+	kindInitial    = "iip"
+	kindInport     = "inport"
+	kindOutport    = "outport"
+)
+
+type element struct {
+	value       interface{}
+	target      *Endpoint
+	fingerprint string
+}
+
+type elements map[string]element
+
+func newElements(g *Description) elements {
+	els := elements{}
+	if g == nil {
+		return els
+	}
+	for k, v := range g.Properties {
+		els.add(kindProperty, k, v, nil)
+	}
+	for name, p := range g.Processes {
+		els.add(kindProcess, name, p, nil)
+	}
+	iips := map[string][]string{}
+	targets := map[string]*Endpoint{}
+	for _, c := range g.Connections {
+		if c.Tgt == nil {
+			continue
+		}
+		if c.Src == nil {
+			key := normalizedEndpoint(c.Tgt)
+			iips[key] = append(iips[key], c.Data)
+			targets[key] = c.Tgt
+			continue
+		}
+		els.add(kindConnection, normalizedEndpoint(c.Src)+" -> "+normalizedEndpoint(c.Tgt), c, nil)
+	}
+	for key, data := range iips {
+		els.add(kindInitial, key, data, targets[key])
+	}
+	for _, e := range g.Inports {
+		els.add(kindInport, e.Public, e, nil)
+	}
+	for _, e := range g.Outports {
+		els.add(kindOutport, e.Public, e, nil)
+	}
+	return els
+}
+
+// processes returns names of the processes an element refers to
+func (el element) processes() []string {
+	switch v := el.value.(type) {
+	case Connection:
+		return []string{v.Src.Process, v.Tgt.Process}
+	case Export:
+		return []string{exportProcess(v)}
+	}
+	if el.target != nil {
+		return []string{el.target.Process}
+	}
+	return nil
+}
+
+func (els elements) add(kind, name string, value interface{}, target *Endpoint) {
+	fingerprint := value
+	if c, ok := value.(Connection); ok {
+		// port names are case-insensitive, so compare only metadata of connections
+		fingerprint = c.Metadata
+	}
+	if e, ok := value.(Export); ok {
+		fingerprint = strings.ToLower(e.Private)
+	}
+	els[kind+":"+name] = element{
+		value:       value,
+		target:      target,
+		fingerprint: jsonString(fingerprint),
+	}
+}
+
+// description builds a graph from the elements keeping ordering of the
+// connections and exports of the given graphs
+func (els elements) description(graphs ...*Description) *Description {
+	g := NewDescription()
+	seen := map[string]bool{}
+	for _, d := range graphs {
+		if d == nil {
+			continue
+		}
+		all := newElements(d)
+		for _, c := range d.Connections {
+			if c.Tgt == nil {
+				continue
+			}
+			key := kindInitial + ":" + normalizedEndpoint(c.Tgt)
+			if c.Src != nil {
+				key = kindConnection + ":" + normalizedEndpoint(c.Src) + " -> " + normalizedEndpoint(c.Tgt)
+			}
+			if el, ok := els[key]; ok && !seen[key] {
+				seen[key] = true
+				g.addElement(key, el)
+			}
+		}
+		for _, key := range sortedKeys(all) {
+			if el, ok := els[key]; ok && !seen[key] {
+				seen[key] = true
+				g.addElement(key, el)
+			}
+		}
+	}
+	return g
+}
+
+func (g *Description) addElement(key string, el element) {
+	kind, name := splitElementKey(key)
+	switch kind {
+	case kindProperty:
+		g.Properties[name] = el.value.(string)
+	case kindProcess:
+		g.Processes[name] = el.value.(Process)
+	case kindConnection:
+		g.Connections = append(g.Connections, el.value.(Connection))
+	case kindInitial:
+		for _, data := range el.value.([]string) {
+			g.Connections = append(g.Connections, Connection{Data: data, Tgt: el.target})
+		}
+	case kindInport:
+		g.Inports = append(g.Inports, el.value.(Export))
+	case kindOutport:
+		g.Outports = append(g.Outports, el.value.(Export))
+	}
+}
+
+func normalizedEndpoint(e *Endpoint) string {
+	if e.Index != nil {
+		return fmt.Sprintf("%s.%s[%v]", e.Process, strings.ToLower(e.Port), *e.Index)
+	}
+	return e.Process + "." + strings.ToLower(e.Port)
+}
+
+func splitElementKey(key string) (string, string) {
+	parts := strings.SplitN(key, ":", 2)
+	return parts[0], parts[1]
+}
+
+// unionKeys returns sorted keys of all given element sets
+func unionKeys(sets ...elements) []string {
+	union := elements{}
+	for _, els := range sets {
+		for k, v := range els {
+			union[k] = v
+		}
+	}
+	return sortedKeys(union)
+}
+
+// sortedKeys returns keys ordered by kind (in the order of Diff fields) and name
+func sortedKeys(els elements) []string {
+	order := map[string]int{kindProperty: 0, kindProcess: 1, kindConnection: 2, kindInitial: 3, kindInport: 4, kindOutport: 5}
+	keys := make([]string, 0, len(els))
+	for k := range els {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki, ni := splitElementKey(keys[i])
+		kj, nj := splitElementKey(keys[j])
+		if ki != kj {
+			return order[ki] < order[kj]
+		}
+		return ni < nj
+	})
+	return keys
+}
+
+func jsonString(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func changeMark(t ChangeType) string {
+	switch t {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return "~"
+}
+
+func changeValues(t ChangeType, old, updated string) string {
+	switch t {
+	case Added:
+		return updated
+	case Removed:
+		return old
+	}
+	return old + " => " + updated
+}
+
+func metadataString(m Metadata) string {
+	if len(m) == 0 {
+		return ""
+	}
+	return " " + jsonString(m)
+}
+
+func quoteAll(data []string) string {
+	quoted := make([]string, len(data))
+	for i, d := range data {
+		quoted[i] = "'" + d + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func exportChangeString(c ExportChange) string {
+	var old, updated string
+	if c.Old != nil {
+		old = c.Old.Private
+	}
+	if c.New != nil {
+		updated = c.New.Private
+	}
+	return c.Name + ": " + changeValues(c.Type, old, updated)
+}
@@ -0,0 +1,268 @@
+package graph
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testGraph returns a small graph used as a base by the tests:
+//
+//	'x' -> OPT A(core/a) OUT -> IN B(core/b)
+//	INPORT=A.IN:IN, OUTPORT=B.OUT:OUT
+func testGraph() *Description {
+	d := NewDescription()
+	d.Properties["name"] = "test"
+	d.Processes["A"] = Process{Component: "core/a"}
+	d.Processes["B"] = Process{Component: "core/b"}
+	d.Connections = []Connection{
+		{Data: "x", Tgt: NewEndpoint("A", "OPT")},
+		{Src: NewEndpoint("A", "OUT"), Tgt: NewEndpoint("B", "IN")},
+	}
+	d.Inports = []Export{{Private: "A.IN", Public: "IN"}}
+	d.Outports = []Export{{Private: "B.OUT", Public: "OUT"}}
+	return d
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(d *Description)
+		diff   []string
+	}{
+		{
+			name:   "unchanged",
+			change: func(d *Description) {},
+			diff:   []string{},
+		},
+		{
+			name:   "port names are case-insensitive",
+			change: func(d *Description) { d.Connections[1].Src.Port = "out" },
+			diff:   []string{},
+		},
+		{
+			name:   "property changed",
+			change: func(d *Description) { d.Properties["name"] = "other" },
+			diff:   []string{"~ property name: test => other"},
+		},
+		{
+			name:   "process added",
+			change: func(d *Description) { d.Processes["C"] = Process{Component: "core/c"} },
+			diff:   []string{"+ process C: core/c"},
+		},
+		{
+			name: "process removed",
+			change: func(d *Description) {
+				d.RemoveProcess("B")
+			},
+			diff: []string{
+				"- process B: core/b",
+				"- connection A OUT -> IN B",
+				"- outport OUT: B.OUT",
+			},
+		},
+		{
+			name:   "process component changed",
+			change: func(d *Description) { d.Processes["A"] = Process{Component: "core/c"} },
+			diff:   []string{"~ process A: core/a => core/c"},
+		},
+		{
+			name:   "connection added",
+			change: func(d *Description) { d.Connect(NewEndpoint("B", "OUT"), NewEndpoint("A", "IN")) },
+			diff:   []string{"+ connection B OUT -> IN A"},
+		},
+		{
+			name:   "connection removed",
+			change: func(d *Description) { d.Disconnect(NewEndpoint("A", "OUT"), NewEndpoint("B", "IN")) },
+			diff:   []string{"- connection A OUT -> IN B"},
+		},
+		{
+			name:   "connection metadata changed",
+			change: func(d *Description) { d.Connections[1].Metadata = Metadata{"buffer": "10"} },
+			diff:   []string{"~ connection A OUT -> IN B"},
+		},
+		{
+			name:   "iip added",
+			change: func(d *Description) { d.AddInitial("y", NewEndpoint("B", "OPT")) },
+			diff:   []string{"+ iip OPT B: 'y'"},
+		},
+		{
+			name:   "iip removed",
+			change: func(d *Description) { d.RemoveInitial(NewEndpoint("A", "OPT")) },
+			diff:   []string{"- iip OPT A: 'x'"},
+		},
+		{
+			name:   "iip changed",
+			change: func(d *Description) { d.Connections[0].Data = "y" },
+			diff:   []string{"~ iip OPT A: 'x' => 'y'"},
+		},
+		{
+			name:   "export added",
+			change: func(d *Description) { d.Export("ERR", "B", "ERR", false) },
+			diff:   []string{"+ outport ERR: B.ERR"},
+		},
+		{
+			name:   "export removed",
+			change: func(d *Description) { d.Unexport("IN", true) },
+			diff:   []string{"- inport IN: A.IN"},
+		},
+		{
+			name:   "export changed",
+			change: func(d *Description) { d.Inports[0].Private = "B.IN" },
+			diff:   []string{"~ inport IN: A.IN => B.IN"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := testGraph()
+			tt.change(updated)
+			d := Compare(testGraph(), updated)
+			if got := diffLines(d); !reflect.DeepEqual(got, tt.diff) {
+				t.Errorf("Compare() = %q, want %q", got, tt.diff)
+			}
+			if d.Empty() != (len(tt.diff) == 0) {
+				t.Errorf("Empty() = %v with %v changes", d.Empty(), len(tt.diff))
+			}
+		})
+	}
+}
+
+func TestCompareNil(t *testing.T) {
+	d := Compare(nil, testGraph())
+	if len(d.Processes) != 2 || d.Processes[0].Type != Added {
+		t.Errorf("Compare(nil, g) processes = %+v, want 2 added", d.Processes)
+	}
+}
+
+func TestChangedProcesses(t *testing.T) {
+	updated := testGraph()
+	updated.Processes["C"] = Process{Component: "core/c"}
+	updated.Connections[0].Data = "y"
+	got := Compare(testGraph(), updated).ChangedProcesses()
+	if want := []string{"A", "C"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedProcesses() = %v, want %v", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		ours      func(d *Description)
+		theirs    func(d *Description)
+		processes []string
+		conns     []string
+		conflicts []string
+	}{
+		{
+			name:      "no changes",
+			ours:      func(d *Description) {},
+			theirs:    func(d *Description) {},
+			processes: []string{"A", "B"},
+			conns:     []string{"'x' -> OPT A", "A OUT -> IN B"},
+			conflicts: []string{},
+		},
+		{
+			name:      "changes on different sides",
+			ours:      func(d *Description) { d.AddProcess("C", "core/c", nil) },
+			theirs:    func(d *Description) { d.Connections[0].Data = "y" },
+			processes: []string{"A", "B", "C"},
+			conns:     []string{"'y' -> OPT A", "A OUT -> IN B"},
+			conflicts: []string{},
+		},
+		{
+			name:      "same change on both sides",
+			ours:      func(d *Description) { d.Processes["A"] = Process{Component: "core/c"} },
+			theirs:    func(d *Description) { d.Processes["A"] = Process{Component: "core/c"} },
+			processes: []string{"A", "B"},
+			conns:     []string{"'x' -> OPT A", "A OUT -> IN B"},
+			conflicts: []string{},
+		},
+		{
+			name:      "removed on one side",
+			ours:      func(d *Description) {},
+			theirs:    func(d *Description) { d.Disconnect(NewEndpoint("A", "OUT"), NewEndpoint("B", "IN")) },
+			processes: []string{"A", "B"},
+			conns:     []string{"'x' -> OPT A"},
+			conflicts: []string{},
+		},
+		{
+			name:      "conflicting changes keep ours",
+			ours:      func(d *Description) { d.Connections[0].Data = "ours" },
+			theirs:    func(d *Description) { d.Connections[0].Data = "theirs" },
+			processes: []string{"A", "B"},
+			conns:     []string{"'ours' -> OPT A", "A OUT -> IN B"},
+			conflicts: []string{"iip:A.opt"},
+		},
+		{
+			name:      "removed in ours, changed in theirs",
+			ours:      func(d *Description) { delete(d.Properties, "name") },
+			theirs:    func(d *Description) { d.Properties["name"] = "other" },
+			processes: []string{"A", "B"},
+			conns:     []string{"'x' -> OPT A", "A OUT -> IN B"},
+			conflicts: []string{"property:name"},
+		},
+		{
+			name: "connection added to a process removed on the other side",
+			ours: func(d *Description) { d.RemoveProcess("B") },
+			theirs: func(d *Description) {
+				d.AddProcess("C", "core/c", nil)
+				d.Connect(NewEndpoint("B", "ERR"), NewEndpoint("C", "IN"))
+				d.AddInitial("y", NewEndpoint("B", "OPT"))
+				d.Export("ERR", "B", "ERR", false)
+			},
+			processes: []string{"A", "C"},
+			conns:     []string{"'x' -> OPT A"},
+			conflicts: []string{"connection:B.err -> C.in", "iip:B.opt", "outport:ERR"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ours, theirs := testGraph(), testGraph()
+			tt.ours(ours)
+			tt.theirs(theirs)
+			merged, conflicts := Merge(testGraph(), ours, theirs)
+
+			processes := []string{}
+			for name := range merged.Processes {
+				processes = append(processes, name)
+			}
+			sort.Strings(processes)
+			if !reflect.DeepEqual(processes, tt.processes) {
+				t.Errorf("processes = %v, want %v", processes, tt.processes)
+			}
+			if conns := connectionStrings(merged); !reflect.DeepEqual(conns, tt.conns) {
+				t.Errorf("connections = %q, want %q", conns, tt.conns)
+			}
+			keys := []string{}
+			for _, c := range conflicts {
+				keys = append(keys, c.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.conflicts) {
+				t.Errorf("conflicts = %q, want %q", keys, tt.conflicts)
+			}
+			if err := merged.Validate(); err != nil {
+				t.Errorf("merged graph is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func diffLines(d *Diff) []string {
+	lines := []string{}
+	for _, l := range strings.Split(d.String(), "\n") {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func connectionStrings(d *Description) []string {
+	conns := []string{}
+	for _, c := range d.Connections {
+		conns = append(conns, c.String())
+	}
+	sort.Strings(conns)
+	return conns
+}
@@ -0,0 +1,339 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrProcessExists describes a case when a process with the same name is already defined
+	ErrProcessExists = errors.New("Process already exists")
+	// ErrProcessNotFound describes a case when a process is not defined in the graph
+	ErrProcessNotFound = errors.New("Process not found")
+	// ErrConnectionExists describes a case when the same connection is already defined
+	ErrConnectionExists = errors.New("Connection already exists")
+	// ErrConnectionNotFound describes a case when a connection is not defined in the graph
+	ErrConnectionNotFound = errors.New("Connection not found")
+	// ErrExportExists describes a case when a port is already exported under the same name
+	ErrExportExists = errors.New("Export already exists")
+	// ErrExportNotFound describes a case when an exported port is not defined in the graph
+	ErrExportNotFound = errors.New("Export not found")
+	// ErrInvalidEndpoint describes a case when an endpoint misses process or port
+	ErrInvalidEndpoint = errors.New("Invalid endpoint")
+)
+
+// NewEndpoint is an Endpoint constructor. Index is optional and should be
+// provided only for array ports
+func NewEndpoint(process, port string, index ...int) *Endpoint {
+	e := &Endpoint{
+		Process: process,
+		Port:    port,
+	}
+	if len(index) > 0 {
+		i := index[0]
+		e.Index = &i
+	}
+	return e
+}
+
+// Equal returns true if both endpoints refer to the same port
+func (endpoint *Endpoint) Equal(other *Endpoint) bool {
+	if endpoint == nil || other == nil {
+		return endpoint == other
+	}
+	if endpoint.Process != other.Process || !strings.EqualFold(endpoint.Port, other.Port) {
+		return false
+	}
+	if endpoint.Index == nil || other.Index == nil {
+		return endpoint.Index == other.Index
+	}
+	return *endpoint.Index == *other.Index
+}
+
+func (endpoint *Endpoint) clone() *Endpoint {
+	if endpoint == nil {
+		return nil
+	}
+	e := *endpoint
+	if endpoint.Index != nil {
+		i := *endpoint.Index
+		e.Index = &i
+	}
+	return &e
+}
+
+// AddProcess adds a new process of a given component to the graph
+func (d *Description) AddProcess(name, component string, metadata map[string]string) error {
+	if name == "" || component == "" {
+		return fmt.Errorf("Process name and component are required")
+	}
+	if _, ok := d.Processes[name]; ok {
+		return fmt.Errorf("%w: %s", ErrProcessExists, name)
+	}
+	d.Processes[name] = Process{
+		Component: component,
+		Metadata:  copyMetadata(metadata),
+	}
+	return nil
+}
+
+// RemoveProcess removes a process with all its connections, IIPs and exported ports
+func (d *Description) RemoveProcess(name string) error {
+	if _, ok := d.Processes[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrProcessNotFound, name)
+	}
+	delete(d.Processes, name)
+
+	connections := []Connection{}
+	for _, c := range d.Connections {
+		if (c.Src != nil && c.Src.Process == name) || (c.Tgt != nil && c.Tgt.Process == name) {
+			continue
+		}
+		connections = append(connections, c)
+	}
+	d.Connections = connections
+
+	d.Inports = filterExports(d.Inports, func(e Export) bool { return exportProcess(e) != name })
+	d.Outports = filterExports(d.Outports, func(e Export) bool { return exportProcess(e) != name })
+	return nil
+}
+
+// RenameProcess renames a process updating all its connections and exported ports
+func (d *Description) RenameProcess(oldName, newName string) error {
+	p, ok := d.Processes[oldName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrProcessNotFound, oldName)
+	}
+	if _, ok := d.Processes[newName]; ok {
+		return fmt.Errorf("%w: %s", ErrProcessExists, newName)
+	}
+	delete(d.Processes, oldName)
+	d.Processes[newName] = p
+
+	for i := range d.Connections {
+		if d.Connections[i].Src != nil && d.Connections[i].Src.Process == oldName {
+			d.Connections[i].Src.Process = newName
+		}
+		if d.Connections[i].Tgt != nil && d.Connections[i].Tgt.Process == oldName {
+			d.Connections[i].Tgt.Process = newName
+		}
+	}
+	rename := func(exports []Export) {
+		for i := range exports {
+			if exportProcess(exports[i]) == oldName {
+				exports[i].Private = newName + strings.TrimPrefix(exports[i].Private, oldName)
+			}
+		}
+	}
+	rename(d.Inports)
+	rename(d.Outports)
+	return nil
+}
+
+// Connect adds a connection between two ports of the existing processes
+func (d *Description) Connect(src, tgt *Endpoint) error {
+	if err := d.checkEndpoint(src); err != nil {
+		return err
+	}
+	if err := d.checkEndpoint(tgt); err != nil {
+		return err
+	}
+	if _, ok := d.findConnection(src, tgt); ok {
+		return fmt.Errorf("%w: %s", ErrConnectionExists, (&Connection{Src: src, Tgt: tgt}).String())
+	}
+	d.Connections = append(d.Connections, Connection{
+		Src: src.clone(),
+		Tgt: tgt.clone(),
+	})
+	return nil
+}
+
+// Disconnect removes a connection between two ports
+func (d *Description) Disconnect(src, tgt *Endpoint) error {
+	if src == nil || tgt == nil {
+		return ErrInvalidEndpoint
+	}
+	i, ok := d.findConnection(src, tgt)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrConnectionNotFound, (&Connection{Src: src, Tgt: tgt}).String())
+	}
+	d.Connections = append(d.Connections[:i], d.Connections[i+1:]...)
+	return nil
+}
+
+// AddInitial adds an IIP to be sent to a given port when the network starts
+func (d *Description) AddInitial(data string, tgt *Endpoint) error {
+	if err := d.checkEndpoint(tgt); err != nil {
+		return err
+	}
+	d.Connections = append(d.Connections, Connection{
+		Data: data,
+		Tgt:  tgt.clone(),
+	})
+	return nil
+}
+
+// RemoveInitial removes all IIPs sent to a given port
+func (d *Description) RemoveInitial(tgt *Endpoint) error {
+	if tgt == nil {
+		return ErrInvalidEndpoint
+	}
+	connections := []Connection{}
+	for _, c := range d.Connections {
+		if c.Src == nil && c.Tgt.Equal(tgt) {
+			continue
+		}
+		connections = append(connections, c)
+	}
+	if len(connections) == len(d.Connections) {
+		return fmt.Errorf("%w: IIP to %s", ErrConnectionNotFound, tgt.String(false))
+	}
+	d.Connections = connections
+	return nil
+}
+
+// Export exposes a port of a process as graph's inport/outport with a given public name
+func (d *Description) Export(public, process, port string, isInput bool) error {
+	if err := d.checkEndpoint(&Endpoint{Process: process, Port: port}); err != nil {
+		return err
+	}
+	exports := &d.Outports
+	if isInput {
+		exports = &d.Inports
+	}
+	for _, e := range *exports {
+		if e.Public == public {
+			return fmt.Errorf("%w: %s", ErrExportExists, public)
+		}
+	}
+	*exports = append(*exports, Export{
+		Private: process + "." + port,
+		Public:  public,
+	})
+	return nil
+}
+
+// Unexport removes an exported inport/outport by its public name
+func (d *Description) Unexport(public string, isInput bool) error {
+	exports := &d.Outports
+	if isInput {
+		exports = &d.Inports
+	}
+	filtered := filterExports(*exports, func(e Export) bool { return e.Public != public })
+	if len(filtered) == len(*exports) {
+		return fmt.Errorf("%w: %s", ErrExportNotFound, public)
+	}
+	*exports = filtered
+	return nil
+}
+
+// Clone returns a deep copy of the graph description
+func (d *Description) Clone() *Description {
+	c := NewDescription()
+	for k, v := range d.Properties {
+		c.Properties[k] = v
+	}
+	for name, p := range d.Processes {
+		c.Processes[name] = Process{
+			Component: p.Component,
+			Metadata:  copyMetadata(p.Metadata),
+		}
+	}
+	for _, conn := range d.Connections {
+		c.Connections = append(c.Connections, Connection{
+			Data:     conn.Data,
+			Src:      conn.Src.clone(),
+			Tgt:      conn.Tgt.clone(),
+			Metadata: copyMetadata(conn.Metadata),
+		})
+	}
+	c.Inports = append(c.Inports, d.Inports...)
+	c.Outports = append(c.Outports, d.Outports...)
+	return c
+}
+
+// Validate checks the graph for dangling references and duplicates
+func (d *Description) Validate() error {
+	for i, c := range d.Connections {
+		if c.Tgt == nil {
+			return fmt.Errorf("%w: connection #%v has no target", ErrInvalidEndpoint, i)
+		}
+		if err := d.checkEndpoint(c.Tgt); err != nil {
+			return err
+		}
+		if c.Src == nil {
+			continue
+		}
+		if err := d.checkEndpoint(c.Src); err != nil {
+			return err
+		}
+		for _, other := range d.Connections[:i] {
+			if c.Src.Equal(other.Src) && c.Tgt.Equal(other.Tgt) {
+				return fmt.Errorf("%w: %s", ErrConnectionExists, c.String())
+			}
+		}
+	}
+	for _, exports := range [][]Export{d.Inports, d.Outports} {
+		seen := map[string]bool{}
+		for _, e := range exports {
+			if seen[e.Public] {
+				return fmt.Errorf("%w: %s", ErrExportExists, e.Public)
+			}
+			seen[e.Public] = true
+			parts := strings.SplitN(e.Private, ".", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("%w: exported port %s", ErrInvalidEndpoint, e.Private)
+			}
+			if err := d.checkEndpoint(&Endpoint{Process: parts[0], Port: parts[1]}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkEndpoint makes sure endpoint is complete and refers to an existing process
+func (d *Description) checkEndpoint(e *Endpoint) error {
+	if e == nil || e.Process == "" || e.Port == "" {
+		return ErrInvalidEndpoint
+	}
+	if _, ok := d.Processes[e.Process]; !ok {
+		return fmt.Errorf("%w: %s", ErrProcessNotFound, e.Process)
+	}
+	return nil
+}
+
+func (d *Description) findConnection(src, tgt *Endpoint) (int, bool) {
+	for i, c := range d.Connections {
+		if c.Src != nil && c.Src.Equal(src) && c.Tgt.Equal(tgt) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func exportProcess(e Export) string {
+	return strings.SplitN(e.Private, ".", 2)[0]
+}
+
+func filterExports(exports []Export, keep func(Export) bool) []Export {
+	result := []Export{}
+	for _, e := range exports {
+		if keep(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	c := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(d *Description) error
+		err     error
+		conns   []string
+		exports []string
+	}{
+		{
+			name:    "add process",
+			edit:    func(d *Description) error { return d.AddProcess("C", "core/c", nil) },
+			conns:   []string{"'x' -> OPT A", "A OUT -> IN B"},
+			exports: []string{"IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "add existing process",
+			edit: func(d *Description) error { return d.AddProcess("A", "core/c", nil) },
+			err:  ErrProcessExists,
+		},
+		{
+			name:    "remove process with its connections and exports",
+			edit:    func(d *Description) error { return d.RemoveProcess("A") },
+			conns:   []string{},
+			exports: []string{"OUT=B.OUT"},
+		},
+		{
+			name: "remove missing process",
+			edit: func(d *Description) error { return d.RemoveProcess("C") },
+			err:  ErrProcessNotFound,
+		},
+		{
+			name:    "rename process",
+			edit:    func(d *Description) error { return d.RenameProcess("A", "C") },
+			conns:   []string{"'x' -> OPT C", "C OUT -> IN B"},
+			exports: []string{"IN=C.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "rename to existing process",
+			edit: func(d *Description) error { return d.RenameProcess("A", "B") },
+			err:  ErrProcessExists,
+		},
+		{
+			name:    "connect",
+			edit:    func(d *Description) error { return d.Connect(NewEndpoint("B", "OUT", 1), NewEndpoint("A", "IN")) },
+			conns:   []string{"'x' -> OPT A", "A OUT -> IN B", "B OUT[1] -> IN A"},
+			exports: []string{"IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "connect twice",
+			edit: func(d *Description) error { return d.Connect(NewEndpoint("A", "out"), NewEndpoint("B", "IN")) },
+			err:  ErrConnectionExists,
+		},
+		{
+			name: "connect missing process",
+			edit: func(d *Description) error { return d.Connect(NewEndpoint("A", "OUT"), NewEndpoint("C", "IN")) },
+			err:  ErrProcessNotFound,
+		},
+		{
+			name: "connect incomplete endpoint",
+			edit: func(d *Description) error { return d.Connect(NewEndpoint("A", ""), NewEndpoint("B", "IN")) },
+			err:  ErrInvalidEndpoint,
+		},
+		{
+			name:    "disconnect",
+			edit:    func(d *Description) error { return d.Disconnect(NewEndpoint("A", "OUT"), NewEndpoint("B", "IN")) },
+			conns:   []string{"'x' -> OPT A"},
+			exports: []string{"IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "disconnect missing connection",
+			edit: func(d *Description) error { return d.Disconnect(NewEndpoint("B", "OUT"), NewEndpoint("A", "IN")) },
+			err:  ErrConnectionNotFound,
+		},
+		{
+			name:    "add iip",
+			edit:    func(d *Description) error { return d.AddInitial("y", NewEndpoint("A", "OPT")) },
+			conns:   []string{"'x' -> OPT A", "'y' -> OPT A", "A OUT -> IN B"},
+			exports: []string{"IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "add iip to missing process",
+			edit: func(d *Description) error { return d.AddInitial("y", NewEndpoint("C", "OPT")) },
+			err:  ErrProcessNotFound,
+		},
+		{
+			name:    "remove iip",
+			edit:    func(d *Description) error { return d.RemoveInitial(NewEndpoint("A", "opt")) },
+			conns:   []string{"A OUT -> IN B"},
+			exports: []string{"IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "remove missing iip",
+			edit: func(d *Description) error { return d.RemoveInitial(NewEndpoint("B", "OPT")) },
+			err:  ErrConnectionNotFound,
+		},
+		{
+			name:    "export",
+			edit:    func(d *Description) error { return d.Export("ERR", "B", "ERR", false) },
+			conns:   []string{"'x' -> OPT A", "A OUT -> IN B"},
+			exports: []string{"ERR=B.ERR", "IN=A.IN", "OUT=B.OUT"},
+		},
+		{
+			name: "export existing name",
+			edit: func(d *Description) error { return d.Export("OUT", "A", "OUT", false) },
+			err:  ErrExportExists,
+		},
+		{
+			name: "export port of missing process",
+			edit: func(d *Description) error { return d.Export("ERR", "C", "ERR", false) },
+			err:  ErrProcessNotFound,
+		},
+		{
+			name:    "unexport",
+			edit:    func(d *Description) error { return d.Unexport("IN", true) },
+			conns:   []string{"'x' -> OPT A", "A OUT -> IN B"},
+			exports: []string{"OUT=B.OUT"},
+		},
+		{
+			name: "unexport missing",
+			edit: func(d *Description) error { return d.Unexport("OUT", true) },
+			err:  ErrExportNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testGraph()
+			err := tt.edit(d)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				if !reflect.DeepEqual(d, testGraph()) {
+					t.Errorf("graph has been modified by a failed edit")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err = d.Validate(); err != nil {
+				t.Errorf("edited graph is invalid: %v", err)
+			}
+			if got := connectionStrings(d); !reflect.DeepEqual(got, tt.conns) {
+				t.Errorf("connections = %q, want %q", got, tt.conns)
+			}
+			if got := exportStrings(d); !reflect.DeepEqual(got, tt.exports) {
+				t.Errorf("exports = %q, want %q", got, tt.exports)
+			}
+		})
+	}
+}
+
+func TestClone(t *testing.T) {
+	d := testGraph()
+	d.Processes["A"] = Process{Component: "core/a", Metadata: Metadata{"x": "1"}}
+	c := d.Clone()
+	if !reflect.DeepEqual(c, d) {
+		t.Fatalf("Clone() = %+v, want %+v", c, d)
+	}
+	c.Processes["A"].Metadata["x"] = "2"
+	c.Connections[1].Tgt.Process = "C"
+	c.Inports[0].Public = "OTHER"
+	if d.Processes["A"].Metadata["x"] != "1" || d.Connections[1].Tgt.Process != "B" || d.Inports[0].Public != "IN" {
+		t.Errorf("changes of the clone are visible in the original graph")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(d *Description)
+		err     error
+	}{
+		{"valid", func(d *Description) {}, nil},
+		{"connection without target", func(d *Description) { d.Connections[1].Tgt = nil }, ErrInvalidEndpoint},
+		{"connection to missing process", func(d *Description) { d.Connections[1].Tgt.Process = "C" }, ErrProcessNotFound},
+		{"iip to missing process", func(d *Description) { d.Connections[0].Tgt.Process = "C" }, ErrProcessNotFound},
+		{"duplicate connection", func(d *Description) { d.Connections = append(d.Connections, d.Connections[1]) }, ErrConnectionExists},
+		{"duplicate export", func(d *Description) { d.Inports = append(d.Inports, d.Inports[0]) }, ErrExportExists},
+		{"export without port", func(d *Description) { d.Outports[0].Private = "B" }, ErrInvalidEndpoint},
+		{"export of missing process", func(d *Description) { d.Outports[0].Private = "C.OUT" }, ErrProcessNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testGraph()
+			tt.corrupt(d)
+			err := d.Validate()
+			if (tt.err == nil && err != nil) || !errors.Is(err, tt.err) {
+				t.Errorf("Validate() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func exportStrings(d *Description) []string {
+	exports := []string{}
+	for _, e := range append(append([]Export{}, d.Inports...), d.Outports...) {
+		exports = append(exports, e.Public+"="+e.Private)
+	}
+	sort.Strings(exports)
+	return exports
+}
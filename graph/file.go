@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ParseFile reads a given graph file and parses it according to its
+// extension (.fbp or .json)
+func ParseFile(graphfile string) (*Description, error) {
+	data, err := ioutil.ReadFile(graphfile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read graph definition from file: %s", err.Error())
+	}
+	var g *Description
+	if strings.HasSuffix(graphfile, ".fbp") {
+		g, err = ParseFBP(data)
+	} else if strings.HasSuffix(graphfile, ".json") {
+		g, err = ParseJSON(data)
+	} else {
+		return nil, fmt.Errorf("Unsupported graph format (should be .fbp or .json): %s", graphfile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse graph definition: %s", err.Error())
+	}
+	return g, nil
+}
+
+// IsGraphFile returns true if a given path looks like a graph definition
+func IsGraphFile(path string) bool {
+	return strings.HasSuffix(path, ".fbp") || strings.HasSuffix(path, ".json")
+}
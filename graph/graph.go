@@ -2,29 +2,54 @@ package graph
 
 import (
 	"fmt"
+	"strings"
 )
 
 // Description describes FBP network
 type Description struct {
 	Properties  map[string]string  `json:"properties"`
-	Processes   map[string]Process `json:"processes`
+	Processes   map[string]Process `json:"processes"`
 	Connections []Connection       `json:"connections"`
 	Inports     []Export           `json:"inports"`
-	Outports    []Export           `json:"outports`
+	Outports    []Export           `json:"outports"`
 }
 
+// Metadata of the process or connection
+type Metadata map[string]string
+
+// VersionMetadata is the key of process metadata pinning the version of
+// the component (alternatively to component@version)
+const VersionMetadata = "version"
+
 // Process of the network
 type Process struct {
-	Component string
-	Metadata  map[string]string `json:"omitempty"`
+	Component string   `json:"component"`
+	Metadata  Metadata `json:"metadata,omitempty"`
+}
+
+// ComponentRef returns the component of the process with a pinned version
+// if any (e.g. core/readfile@1.2). Version may be pinned either in the
+// component name or in the metadata, but both must agree
+func (p Process) ComponentRef() (string, error) {
+	version, ok := p.Metadata[VersionMetadata]
+	if !ok || version == "" {
+		return p.Component, nil
+	}
+	if i := strings.LastIndex(p.Component, "@"); i >= 0 {
+		if p.Component[i+1:] != version {
+			return "", fmt.Errorf("Component %s is pinned to version %s in metadata", p.Component, version)
+		}
+		return p.Component, nil
+	}
+	return p.Component + "@" + version, nil
 }
 
 // Connection between processes in the network
 type Connection struct {
-	Data     string            `json:"data,omitempty"`
-	Src      *Endpoint         `json:"src,omitempty"`
-	Tgt      *Endpoint         `json:"tgt,omitempty"`
-	Metadata map[string]string `json:"omitempty"`
+	Data     string    `json:"data,omitempty"`
+	Src      *Endpoint `json:"src,omitempty"`
+	Tgt      *Endpoint `json:"tgt,omitempty"`
+	Metadata Metadata  `json:"metadata,omitempty"`
 }
 
 // Endpoint of the process
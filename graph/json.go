@@ -15,3 +15,29 @@ func ParseJSON(definition []byte) (*Description, error) {
 
 	return &graph, nil
 }
+
+// UnmarshalJSON decodes metadata converting non-string values (e.g. NoFlo's
+// x/y coordinates of the nodes) into their JSON representation
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*m = nil
+		return nil
+	}
+	*m = make(Metadata, len(raw))
+	for k, v := range raw {
+		if str, ok := v.(string); ok {
+			(*m)[k] = str
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		(*m)[k] = string(b)
+	}
+	return nil
+}
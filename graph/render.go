@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Diagram is a graph description prepared for rendering. Subgraphs map
+// contains (optionally) expanded descriptions of composite processes
+// which are rendered as clusters
+type Diagram struct {
+	Graph     *Description
+	Subgraphs map[string]*Diagram
+}
+
+// NewDiagram is a Diagram constructor
+func NewDiagram(g *Description) *Diagram {
+	return &Diagram{
+		Graph:     g,
+		Subgraphs: make(map[string]*Diagram),
+	}
+}
+
+const (
+	nodeProcess = iota
+	nodeIIP
+	nodeInport
+	nodeOutport
+)
+
+type diagramNode struct {
+	ID    string
+	Label string
+	Kind  int
+}
+
+type diagramEdge struct {
+	From  string
+	To    string
+	Label string
+}
+
+type diagramCluster struct {
+	ID       string
+	Label    string
+	Nodes    []diagramNode
+	Clusters []*diagramCluster
+}
+
+type diagramModel struct {
+	Root  *diagramCluster
+	Edges []diagramEdge
+	iips  int
+}
+
+// WriteDOT renders the diagram in Graphviz DOT format
+func (d *Diagram) WriteDOT(w io.Writer) error {
+	m, err := d.model()
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(d.name()))
+	fmt.Fprintln(b, "    rankdir=LR;")
+	fmt.Fprintln(b, "    node [shape=box, style=rounded, fontname=\"Helvetica\"];")
+	fmt.Fprintln(b, "    edge [fontname=\"Helvetica\", fontsize=10];")
+	writeDOTCluster(b, m.Root, "    ")
+	for _, e := range m.Edges {
+		fmt.Fprintf(b, "    %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Label))
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteMermaid renders the diagram in Mermaid flowchart format
+func (d *Diagram) WriteMermaid(w io.Writer) error {
+	m, err := d.model()
+	if err != nil {
+		return err
+	}
+	ids := map[string]string{}
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "graph LR")
+	writeMermaidCluster(b, m.Root, "    ", ids)
+	// endpoints without a node get a placeholder named after them (the way
+	// DOT creates nodes for unknown endpoints)
+	node := func(name string) string {
+		if id, ok := ids[name]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%v", len(ids))
+		ids[name] = id
+		fmt.Fprintf(b, "    %s[%s]\n", id, mermaidQuote(name))
+		return id
+	}
+	for _, e := range m.Edges {
+		from, to := node(e.From), node(e.To)
+		if e.Label == "" {
+			fmt.Fprintf(b, "    %s --> %s\n", from, to)
+		} else {
+			fmt.Fprintf(b, "    %s -- %s --> %s\n", from, mermaidQuote(e.Label), to)
+		}
+	}
+	return b.Flush()
+}
+
+func (d *Diagram) name() string {
+	if name, ok := d.Graph.Properties["name"]; ok && name != "" {
+		return name
+	}
+	return "graph"
+}
+
+// model converts the diagram into a format-independent set of nodes,
+// clusters and edges
+func (d *Diagram) model() (*diagramModel, error) {
+	m := &diagramModel{Root: &diagramCluster{}}
+	if err := d.build(m, m.Root, ""); err != nil {
+		return nil, err
+	}
+	for _, e := range d.Graph.Inports {
+		id := "inport:" + e.Public
+		m.Root.Nodes = append(m.Root.Nodes, diagramNode{ID: id, Label: e.Public, Kind: nodeInport})
+		tgt, err := parseExport(e)
+		if err != nil {
+			return nil, err
+		}
+		to, port, err := d.resolve("", tgt, true)
+		if err != nil {
+			return nil, err
+		}
+		m.Edges = append(m.Edges, diagramEdge{From: id, To: to, Label: port})
+	}
+	for _, e := range d.Graph.Outports {
+		id := "outport:" + e.Public
+		m.Root.Nodes = append(m.Root.Nodes, diagramNode{ID: id, Label: e.Public, Kind: nodeOutport})
+		src, err := parseExport(e)
+		if err != nil {
+			return nil, err
+		}
+		from, port, err := d.resolve("", src, false)
+		if err != nil {
+			return nil, err
+		}
+		m.Edges = append(m.Edges, diagramEdge{From: from, To: id, Label: port})
+	}
+	return m, nil
+}
+
+// build adds processes, IIPs and connections of the diagram into a given
+// cluster, all node identifiers are prefixed with a given prefix
+func (d *Diagram) build(m *diagramModel, c *diagramCluster, prefix string) error {
+	names := make([]string, 0, len(d.Graph.Processes))
+	for name := range d.Graph.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := d.Graph.Processes[name]
+		if sub, ok := d.Subgraphs[name]; ok {
+			cluster := &diagramCluster{
+				ID:    prefix + name,
+				Label: fmt.Sprintf("%s (%s)", name, p.Component),
+			}
+			if err := sub.build(m, cluster, prefix+name+"/"); err != nil {
+				return err
+			}
+			c.Clusters = append(c.Clusters, cluster)
+			continue
+		}
+		c.Nodes = append(c.Nodes, diagramNode{
+			ID:    prefix + name,
+			Label: name + "\n" + p.String(),
+			Kind:  nodeProcess,
+		})
+	}
+
+	for _, conn := range d.Graph.Connections {
+		if conn.Tgt == nil {
+			continue
+		}
+		to, tgtPort, err := d.resolve(prefix, conn.Tgt, true)
+		if err != nil {
+			return err
+		}
+		if conn.Src == nil {
+			id := fmt.Sprintf("iip:%v", m.iips)
+			m.iips++
+			c.Nodes = append(c.Nodes, diagramNode{ID: id, Label: "'" + conn.Data + "'", Kind: nodeIIP})
+			m.Edges = append(m.Edges, diagramEdge{From: id, To: to, Label: tgtPort})
+			continue
+		}
+		from, srcPort, err := d.resolve(prefix, conn.Src, false)
+		if err != nil {
+			return err
+		}
+		m.Edges = append(m.Edges, diagramEdge{From: from, To: to, Label: srcPort + " -> " + tgtPort})
+	}
+	return nil
+}
+
+// resolve returns node identifier and port label for a given endpoint,
+// following exported ports of the expanded subgraphs
+func (d *Diagram) resolve(prefix string, endpoint *Endpoint, isInput bool) (string, string, error) {
+	sub, ok := d.Subgraphs[endpoint.Process]
+	if !ok {
+		return prefix + endpoint.Process, portLabel(endpoint), nil
+	}
+	exports := sub.Graph.Outports
+	if isInput {
+		exports = sub.Graph.Inports
+	}
+	for _, e := range exports {
+		if !strings.EqualFold(e.Public, endpoint.Port) {
+			continue
+		}
+		private, err := parseExport(e)
+		if err != nil {
+			return "", "", err
+		}
+		private.Index = endpoint.Index
+		return sub.resolve(prefix+endpoint.Process+"/", private, isInput)
+	}
+	return "", "", fmt.Errorf("Port %s is not exported by subgraph %s", endpoint.Port, endpoint.Process)
+}
+
+func parseExport(e Export) (*Endpoint, error) {
+	parts := strings.SplitN(e.Private, ".", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid exported port %s", e.Private)
+	}
+	return &Endpoint{Process: parts[0], Port: parts[1]}, nil
+}
+
+func portLabel(endpoint *Endpoint) string {
+	if endpoint.Index != nil {
+		return fmt.Sprintf("%s[%v]", endpoint.Port, *endpoint.Index)
+	}
+	return endpoint.Port
+}
+
+func writeDOTCluster(w io.Writer, c *diagramCluster, indent string) {
+	for _, n := range c.Nodes {
+		switch n.Kind {
+		case nodeIIP:
+			fmt.Fprintf(w, "%s%s [label=%s, shape=note, style=filled, fillcolor=lightyellow];\n", indent, dotQuote(n.ID), dotQuote(n.Label))
+		case nodeInport, nodeOutport:
+			fmt.Fprintf(w, "%s%s [label=%s, shape=cds, style=filled, fillcolor=lightgrey];\n", indent, dotQuote(n.ID), dotQuote(n.Label))
+		default:
+			fmt.Fprintf(w, "%s%s [label=%s];\n", indent, dotQuote(n.ID), dotQuote(n.Label))
+		}
+	}
+	for _, sub := range c.Clusters {
+		fmt.Fprintf(w, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+sub.ID))
+		fmt.Fprintf(w, "%s    label=%s;\n", indent, dotQuote(sub.Label))
+		fmt.Fprintf(w, "%s    style=dashed;\n", indent)
+		writeDOTCluster(w, sub, indent+"    ")
+		fmt.Fprintf(w, "%s}\n", indent)
+	}
+}
+
+func writeMermaidCluster(w io.Writer, c *diagramCluster, indent string, ids map[string]string) {
+	for _, n := range c.Nodes {
+		id := fmt.Sprintf("n%v", len(ids))
+		ids[n.ID] = id
+		label := mermaidQuote(n.Label)
+		switch n.Kind {
+		case nodeIIP:
+			fmt.Fprintf(w, "%s%s>%s]\n", indent, id, label)
+		case nodeInport, nodeOutport:
+			fmt.Fprintf(w, "%s%s((%s))\n", indent, id, label)
+		default:
+			fmt.Fprintf(w, "%s%s(%s)\n", indent, id, label)
+		}
+	}
+	for _, sub := range c.Clusters {
+		id := fmt.Sprintf("c%v", len(ids))
+		ids["cluster:"+sub.ID] = id
+		fmt.Fprintf(w, "%ssubgraph %s [%s]\n", indent, id, mermaidQuote(sub.Label))
+		writeMermaidCluster(w, sub, indent+"    ", ids)
+		fmt.Fprintf(w, "%send\n", indent)
+	}
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "\"", "\\\"", -1)
+	s = strings.Replace(s, "\n", "\\n", -1)
+	return "\"" + s + "\""
+}
+
+func mermaidQuote(s string) string {
+	s = strings.Replace(s, "\"", "#quot;", -1)
+	s = strings.Replace(s, "\n", "<br/>", -1)
+	return "\"" + s + "\""
+}
@@ -0,0 +1,191 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cascades-fbp/cascades/graph"
+)
+
+// extensions of the component files trimmed from the entry names
+var extensions = []string{"", ".exe", ".fbp", ".json"}
+
+// EntryName returns a name of the entry for a component file located in
+// a given components directory (e.g. core/passthru)
+func EntryName(dir, path string) string {
+	sep := string(filepath.Separator)
+	name := strings.TrimPrefix(strings.TrimPrefix(path, dir), sep)
+	name = strings.TrimPrefix(name, sep)
+	name = strings.Replace(name, sep, "/", 1)
+	for _, ext := range extensions[1:] {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+//
+// DirLibrary is a read-only registrar of the components located in
+// a directory. Executables are introspected (with --json flag) only when
+// the entry is requested and cached until the file is modified
+//
+type DirLibrary struct {
+	Root  string
+	mutex sync.Mutex
+	cache map[string]cachedEntry
+}
+
+type cachedEntry struct {
+	entry   Entry
+	modTime time.Time
+	size    int64
+}
+
+// NewDirLibrary is a DirLibrary constructor
+func NewDirLibrary(root string) (*DirLibrary, error) {
+	path, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to access components directory: %s", err.Error())
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	return &DirLibrary{
+		Root:  path,
+		cache: map[string]cachedEntry{},
+	}, nil
+}
+
+// Add does nothing: entries are defined by the directory contents
+func (l *DirLibrary) Add(entry Entry) {
+}
+
+// Exists returns true if a component with a given name is in the directory
+func (l *DirLibrary) Exists(name string) bool {
+	_, err := l.Get(name)
+	return err == nil
+}
+
+// Get returns an entry by given name introspecting the component if needed
+func (l *DirLibrary) Get(name string) (Entry, error) {
+	return l.get(name, map[string]bool{})
+}
+
+func (l *DirLibrary) get(ref string, visiting map[string]bool) (Entry, error) {
+	name, pin := SplitVersion(ref)
+	base := filepath.Join(l.Root, filepath.FromSlash(name))
+	for _, ext := range extensions {
+		info, err := os.Stat(base + ext)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		entry, err := l.load(name, base+ext, info, visiting)
+		if err != nil {
+			return entry, err
+		}
+		if !MatchVersion(entry.Version, pin) {
+			return Entry{}, ErrNotFound
+		}
+		return entry, nil
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Find returns a map of entries which name contains a given term
+func (l *DirLibrary) Find(term string) map[string]Entry {
+	results := map[string]Entry{}
+	for name, e := range l.List() {
+		if strings.Contains(name, term) {
+			results[name] = e
+		}
+	}
+	return results
+}
+
+// List introspects all components in the directory. Files which are not
+// components are skipped
+func (l *DirLibrary) List() map[string]Entry {
+	results := map[string]Entry{}
+	filepath.Walk(l.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != l.Root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name := EntryName(l.Root, path)
+		if entry, err := l.load(name, path, info, map[string]bool{}); err == nil {
+			results[entry.Key()] = entry
+		}
+		return nil
+	})
+	return results
+}
+
+// load returns a cached entry or introspects a component file. Visiting
+// keeps track of the subgraphs being loaded to detect cycles
+func (l *DirLibrary) load(name, path string, info os.FileInfo, visiting map[string]bool) (Entry, error) {
+	l.mutex.Lock()
+	c, ok := l.cache[path]
+	l.mutex.Unlock()
+	if ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.entry, nil
+	}
+
+	var entry *Entry
+	if graph.IsGraphFile(path) {
+		if visiting[path] {
+			return Entry{}, fmt.Errorf("Subgraph %s refers to itself", path)
+		}
+		visiting[path] = true
+		defer delete(visiting, path)
+
+		g, err := graph.ParseFile(path)
+		if err != nil {
+			return Entry{}, err
+		}
+		if entry, err = GraphEntry(g, path, &subgraphLibrary{l, visiting}); err != nil {
+			return Entry{}, err
+		}
+	} else {
+		var err error
+		if entry, err = Introspect(path); err != nil {
+			return Entry{}, err
+		}
+	}
+	entry.Name = name
+	entry.Executable = path
+	result := entry.normalized()
+
+	l.mutex.Lock()
+	l.cache[path] = cachedEntry{
+		entry:   result,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+	l.mutex.Unlock()
+	return result, nil
+}
+
+// subgraphLibrary looks up components of a subgraph being loaded
+type subgraphLibrary struct {
+	*DirLibrary
+	visiting map[string]bool
+}
+
+func (l *subgraphLibrary) Get(name string) (Entry, error) {
+	return l.get(name, l.visiting)
+}
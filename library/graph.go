@@ -0,0 +1,65 @@
+package library
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cascades-fbp/cascades/graph"
+)
+
+// GraphEntry creates an entry of a composite component from a given graph.
+// Ports of the entry are the exported ports of the graph with descriptions
+// taken from the components of a given registrar
+func GraphEntry(g *graph.Description, path string, r Registrar) (*Entry, error) {
+	entry := &Entry{
+		Executable:  path,
+		Version:     g.Properties["version"],
+		Description: g.Properties["name"],
+		Elementary:  false,
+		Inports:     []EntryPort{},
+		Outports:    []EntryPort{},
+	}
+
+	for _, e := range g.Inports {
+		parts := strings.SplitN(e.Private, ".", 2)
+		rec, err := processEntry(g, parts[0], r)
+		if err != nil {
+			return nil, err
+		}
+		port, found := rec.FindInport(strings.ToLower(parts[1]))
+		if !found {
+			return nil, fmt.Errorf("Port %s not found in component %s", parts[1], parts[0])
+		}
+		port.Name = e.Public
+		entry.Inports = append(entry.Inports, port)
+	}
+
+	for _, e := range g.Outports {
+		parts := strings.SplitN(e.Private, ".", 2)
+		rec, err := processEntry(g, parts[0], r)
+		if err != nil {
+			return nil, err
+		}
+		port, found := rec.FindOutport(strings.ToLower(parts[1]))
+		if !found {
+			return nil, fmt.Errorf("Port %s not found in component %s", parts[1], parts[0])
+		}
+		port.Name = e.Public
+		entry.Outports = append(entry.Outports, port)
+	}
+
+	return entry, nil
+}
+
+// processEntry returns an entry of the component used by a given process
+func processEntry(g *graph.Description, process string, r Registrar) (Entry, error) {
+	ref, err := g.Processes[process].ComponentRef()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry, err := r.Get(ref)
+	if err != nil {
+		return entry, fmt.Errorf("Component %s not found in library", process)
+	}
+	return entry, nil
+}
@@ -0,0 +1,119 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//
+// HTTPLibrary is a read-only registrar of a library served over HTTP
+// (see Handler and `cascades serve`)
+//
+type HTTPLibrary struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPLibrary is a HTTPLibrary constructor
+func NewHTTPLibrary(url string) *HTTPLibrary {
+	return &HTTPLibrary{
+		URL:    strings.TrimSuffix(url, "/"),
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Add does nothing: the library is read-only
+func (l *HTTPLibrary) Add(entry Entry) {
+}
+
+// Exists returns true if an entry with a given name exists
+func (l *HTTPLibrary) Exists(name string) bool {
+	_, err := l.Get(name)
+	return err == nil
+}
+
+// Get requests an entry by given name
+func (l *HTTPLibrary) Get(name string) (Entry, error) {
+	var entry Entry
+	err := l.request("/entries/"+name, &entry)
+	return entry, err
+}
+
+// Find requests entries which name contains a given term
+func (l *HTTPLibrary) Find(term string) map[string]Entry {
+	results := map[string]Entry{}
+	l.request("/entries?q="+url.QueryEscape(term), &results)
+	return results
+}
+
+// List requests all entries (empty if the library is unavailable)
+func (l *HTTPLibrary) List() map[string]Entry {
+	results := map[string]Entry{}
+	l.request("/entries", &results)
+	return results
+}
+
+// check makes sure the library is available
+func (l *HTTPLibrary) check() error {
+	results := map[string]Entry{}
+	return l.request("/entries", &results)
+}
+
+func (l *HTTPLibrary) request(path string, result interface{}) error {
+	resp, err := l.Client.Get(l.URL + path)
+	if err != nil {
+		return fmt.Errorf("Failed to request library %s: %s", l.URL, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to request library %s: %s", l.URL, resp.Status)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("Failed to parse response of library %s: %s", l.URL, err.Error())
+	}
+	return nil
+}
+
+//
+// Handler serves a given registrar read-only over HTTP:
+//
+//   GET /entries          all entries
+//   GET /entries?q=term   entries which name contains a term
+//   GET /entries/<name>   a single entry
+//
+func Handler(r Registrar) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/entries", func(w http.ResponseWriter, req *http.Request) {
+		if term := req.URL.Query().Get("q"); term != "" {
+			writeJSON(w, r.Find(term))
+			return
+		}
+		writeJSON(w, r.List())
+	})
+	mux.HandleFunc("/entries/", func(w http.ResponseWriter, req *http.Request) {
+		entry, err := r.Get(strings.TrimPrefix(req.URL.Path, "/entries/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, entry)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
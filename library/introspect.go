@@ -0,0 +1,25 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// Introspect runs an elementary component executable with --json flag and
+// returns the entry it describes itself with
+func Introspect(executable string) (*Entry, error) {
+	out, err := exec.Command(executable, "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to introspect %s: %s", executable, err.Error())
+	}
+	var entry *Entry
+	if err = json.Unmarshal(out, &entry); err != nil {
+		return nil, fmt.Errorf("Failed to parse description of %s: %s", executable, err.Error())
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("Empty description of %s", executable)
+	}
+	entry.Elementary = true
+	return entry, nil
+}
@@ -16,37 +16,89 @@ type JSONLibrary struct {
 	Updated time.Time        `json:"updated"`
 }
 
-// Add a new entry to library
+// Add a new entry to library (replaces an entry of the same version)
 func (l JSONLibrary) Add(entry Entry) {
-	inports := []EntryPort{}
-	outports := []EntryPort{}
-	for _, p := range entry.Inports {
-		p.Name = strings.ToLower(p.Name)
-		inports = append(inports, p)
+	l.Entries[entry.Key()] = entry.normalized()
+}
+
+// Remove deletes an entry with a given name from library. All versions
+// of the component are removed unless a version is given (name@version)
+func (l JSONLibrary) Remove(name string) error {
+	keys := l.keys(name)
+	if len(keys) == 0 {
+		return ErrNotFound
 	}
-	for _, p := range entry.Outports {
-		p.Name = strings.ToLower(p.Name)
-		outports = append(outports, p)
+	for _, k := range keys {
+		delete(l.Entries, k)
 	}
-	entry.Inports = inports
-	entry.Outports = outports
-	l.Entries[entry.Name] = entry
+	return nil
 }
 
-// Exists returns true if an entry with a given name already exists
-func (l JSONLibrary) Exists(name string) bool {
-	_, ok := l.Entries[name]
+// Rename changes the name of an existing entry (of all its versions
+// unless a version is given)
+func (l JSONLibrary) Rename(oldName, newName string) error {
+	keys := l.keys(oldName)
+	if len(keys) == 0 {
+		return ErrNotFound
+	}
+	newName, _ = SplitVersion(newName)
+	renamed := map[string]Entry{}
+	for _, k := range keys {
+		entry := l.Entries[k]
+		entry.Name = newName
+		if _, ok := l.Entries[entry.Key()]; ok {
+			return ErrExists
+		}
+		renamed[entry.Key()] = entry
+	}
+	for _, k := range keys {
+		delete(l.Entries, k)
+	}
+	for k, entry := range renamed {
+		l.Entries[k] = entry
+	}
+	return nil
+}
+
+// Has returns true if an entry with exactly given key (name or
+// name@version) is in the library
+func (l JSONLibrary) Has(key string) bool {
+	_, ok := l.Entries[key]
 	return ok
 }
 
-// Get returns an entry by given name
+// Exists returns true if an entry with a given name (and matching version
+// if given as name@version) already exists
+func (l JSONLibrary) Exists(name string) bool {
+	_, err := l.Get(name)
+	return err == nil
+}
+
+// Get returns an entry by given name. The highest version is returned
+// unless the version is pinned (name@version)
 func (l JSONLibrary) Get(name string) (Entry, error) {
-	if entry, ok := l.Entries[name]; ok {
+	if entry, ok := latest(l.Entries, name); ok {
 		return entry, nil
 	}
 	return Entry{}, ErrNotFound
 }
 
+// keys returns keys of the entries a given reference refers to: the exact
+// entry or all versions of the component if no version is given
+func (l JSONLibrary) keys(ref string) []string {
+	name, pin := SplitVersion(ref)
+	if _, ok := l.Entries[ref]; ok && pin != "" {
+		return []string{ref}
+	}
+	keys := []string{}
+	for k, e := range l.Entries {
+		if e.Name == name && MatchVersion(e.Version, pin) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 // Find returns a map of entries which name contains a given term
 func (l JSONLibrary) Find(term string) map[string]Entry {
 	results := map[string]Entry{}
@@ -2,11 +2,14 @@ package library
 
 import (
 	"errors"
+	"strings"
 )
 
 var (
 	// ErrNotFound describes a case when a component not found
 	ErrNotFound = errors.New("Component not found")
+	// ErrExists describes a case when a component with the same name is already registered
+	ErrExists = errors.New("Component already exists")
 )
 
 //
@@ -25,11 +28,31 @@ type Registrar interface {
 //
 type Entry struct {
 	Name        string      `json:"name"`
+	Version     string      `json:"version,omitempty"`
 	Description string      `json:"description"`
 	Executable  string      `json:"exec"`
 	Elementary  bool        `json:"elementary"`
 	Inports     []EntryPort `json:"inports"`
 	Outports    []EntryPort `json:"outports"`
+	Checksum    string      `json:"checksum,omitempty"`
+	Modified    int64       `json:"mtime,omitempty"`
+}
+
+// normalized returns a copy of the entry with lower-cased port names
+func (entry Entry) normalized() Entry {
+	inports := []EntryPort{}
+	outports := []EntryPort{}
+	for _, p := range entry.Inports {
+		p.Name = strings.ToLower(p.Name)
+		inports = append(inports, p)
+	}
+	for _, p := range entry.Outports {
+		p.Name = strings.ToLower(p.Name)
+		outports = append(outports, p)
+	}
+	entry.Inports = inports
+	entry.Outports = outports
+	return entry
 }
 
 // FindInport looks for an input port by name
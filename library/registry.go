@@ -0,0 +1,185 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//
+// Open creates a registrar for a given library URI. Several URIs separated
+// by commas are combined into a Composite registrar searching them in order
+// (e.g. personal library overlaying a shared one). Supported URIs:
+//
+//   library.json, file:///path/library.json  JSON library file
+//   dir:///path/to/components                directory of components (introspected on demand)
+//   http://host:7878                         read-only library served by `cascades serve`
+//
+func Open(uri string) (Registrar, error) {
+	uris := SplitURIs(uri)
+	if len(uris) == 0 {
+		return nil, fmt.Errorf("Library is not specified")
+	}
+	registrars := []Registrar{}
+	for _, u := range uris {
+		r, err := openURI(u)
+		if err != nil {
+			return nil, err
+		}
+		registrars = append(registrars, r)
+	}
+	if len(registrars) == 1 {
+		return registrars[0], nil
+	}
+	return NewComposite(registrars...), nil
+}
+
+// SplitURIs splits a comma-separated list of library URIs
+func SplitURIs(uri string) []string {
+	uris := []string{}
+	for _, u := range strings.Split(uri, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			uris = append(uris, u)
+		}
+	}
+	return uris
+}
+
+// FilePath returns a local path of the JSON library file if a given URI
+// refers to one
+func FilePath(uri string) (string, bool) {
+	if strings.HasPrefix(uri, "dir://") || strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return "", false
+	}
+	return strings.TrimPrefix(uri, "file://"), true
+}
+
+// LocalFile returns a path of the first JSON library file in a given list
+// of URIs (or empty string if there is none)
+func LocalFile(uri string) string {
+	for _, u := range SplitURIs(uri) {
+		if path, ok := FilePath(u); ok {
+			return path
+		}
+	}
+	return ""
+}
+
+func openURI(uri string) (Registrar, error) {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		l := NewHTTPLibrary(uri)
+		if err := l.check(); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	if strings.HasPrefix(uri, "dir://") {
+		return NewDirLibrary(strings.TrimPrefix(uri, "dir://"))
+	}
+	path, _ := FilePath(uri)
+	l, err := ReadJSONLibrary(path)
+	if err != nil {
+		return nil, err
+	}
+	// entries of several libraries can not share a single resolver root,
+	// so relative executables are resolved against their library file
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for name, e := range l.Entries {
+		if filepath.IsAbs(e.Executable) {
+			continue
+		}
+		executable := filepath.Join(dir, e.Executable)
+		if _, err := os.Stat(executable); err == nil {
+			e.Executable = executable
+			l.Entries[name] = e
+		}
+	}
+	return l, nil
+}
+
+// ReadJSONLibrary reads and parses a given JSON library file
+func ReadJSONLibrary(path string) (*JSONLibrary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read catalogue file: %s", err.Error())
+	}
+	var l JSONLibrary
+	if err = json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("Failed to parse catalogue file: %s", err.Error())
+	}
+	if l.Entries == nil {
+		l.Entries = map[string]Entry{}
+	}
+	return &l, nil
+}
+
+//
+// Composite searches several registrars in order: entries of the first
+// registrars shadow entries with the same names in the next ones. New
+// entries are added to the first registrar
+//
+type Composite struct {
+	Registrars []Registrar
+}
+
+// NewComposite is a Composite constructor
+func NewComposite(registrars ...Registrar) *Composite {
+	return &Composite{
+		Registrars: registrars,
+	}
+}
+
+// Add a new entry to the first registrar
+func (c *Composite) Add(entry Entry) {
+	if len(c.Registrars) > 0 {
+		c.Registrars[0].Add(entry)
+	}
+}
+
+// Exists returns true if any of registrars has an entry with a given name
+func (c *Composite) Exists(name string) bool {
+	for _, r := range c.Registrars {
+		if r.Exists(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns an entry from the first registrar having it
+func (c *Composite) Get(name string) (Entry, error) {
+	for _, r := range c.Registrars {
+		if entry, err := r.Get(name); err == nil {
+			return entry, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Find returns a map of entries which name contains a given term
+func (c *Composite) Find(term string) map[string]Entry {
+	results := map[string]Entry{}
+	for i := len(c.Registrars) - 1; i >= 0; i-- {
+		for name, e := range c.Registrars[i].Find(term) {
+			results[name] = e
+		}
+	}
+	return results
+}
+
+// List returns entries of all registrars
+func (c *Composite) List() map[string]Entry {
+	results := map[string]Entry{}
+	for i := len(c.Registrars) - 1; i >= 0; i-- {
+		for name, e := range c.Registrars[i].List() {
+			results[name] = e
+		}
+	}
+	return results
+}
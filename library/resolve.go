@@ -0,0 +1,84 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SearchPathEnv is the name of environment variable with a list of
+// directories to look up component executables in (separated the same
+// way as PATH on the current platform)
+const SearchPathEnv = "CASCADES_PATH"
+
+// SearchPath returns a list of directories defined in CASCADES_PATH
+func SearchPath() []string {
+	dirs := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv(SearchPathEnv)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+//
+// Resolver resolves (possibly relative) executable paths of the library
+// entries into absolute paths on the local file system
+//
+type Resolver struct {
+	Root  string
+	Paths []string
+}
+
+// NewResolver is a Resolver constructor. The root directory is the one
+// of a given library file (or current working directory if empty)
+func NewResolver(libraryFile string) *Resolver {
+	root, _ := os.Getwd()
+	if libraryFile != "" {
+		if path, err := filepath.Abs(libraryFile); err == nil {
+			root = filepath.Dir(path)
+		}
+	}
+	return &Resolver{
+		Root:  root,
+		Paths: SearchPath(),
+	}
+}
+
+// Resolve returns an absolute path of a given executable. Relative paths
+// are looked up in the given directories first (e.g. directory of a parent
+// graph), then relative to the library file and finally in the search path
+func (r *Resolver) Resolve(executable string, dirs ...string) (string, error) {
+	if filepath.IsAbs(executable) {
+		return executable, nil
+	}
+	candidates := append([]string{}, dirs...)
+	candidates = append(candidates, r.Root)
+	candidates = append(candidates, r.Paths...)
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, executable)
+		if _, err := os.Stat(path); err == nil {
+			return filepath.Abs(path)
+		}
+	}
+	return "", fmt.Errorf("Executable %s not found (looked in %v)", executable, candidates)
+}
+
+// Relative returns a path relative to the library file directory if
+// a given path is located under it, otherwise returns absolute path
+func (r *Resolver) Relative(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(r.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, nil
+	}
+	return rel, nil
+}
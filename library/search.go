@@ -0,0 +1,130 @@
+package library
+
+import (
+	"sort"
+	"strings"
+)
+
+// Query describes a search in the library
+type Query struct {
+	// Terms are matched against names, descriptions and ports of the entries
+	Terms []string
+	// InType/OutType (if not empty) require an inport/outport of such type
+	InType  string
+	OutType string
+}
+
+// Result is a single entry found by Search
+type Result struct {
+	Key     string   `json:"key"`
+	Entry   Entry    `json:"entry"`
+	Score   int      `json:"score"`
+	Matches []string `json:"matches"`
+}
+
+// words which are ignored in free-form queries
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "or": true, "the": true, "that": true,
+	"which": true, "with": true, "on": true, "in": true, "to": true, "of": true,
+	"for": true, "from": true, "it": true, "component": true, "components": true,
+}
+
+// Search looks for entries matching a given query. Results are ranked by
+// relevance: name matches weigh more than description and port matches
+func Search(r Registrar, q Query) []Result {
+	terms := []string{}
+	for _, t := range q.Terms {
+		for _, w := range strings.Fields(strings.ToLower(t)) {
+			if !stopWords[w] {
+				terms = append(terms, w)
+			}
+		}
+	}
+
+	results := []Result{}
+	for key, e := range r.List() {
+		res := Result{Key: key, Entry: e, Matches: []string{}}
+		if q.InType != "" && !res.matchType(e.Inports, q.InType, "inport") {
+			continue
+		}
+		if q.OutType != "" && !res.matchType(e.Outports, q.OutType, "outport") {
+			continue
+		}
+		for _, t := range terms {
+			res.matchTerm(t)
+		}
+		if len(terms) > 0 && res.Score == 0 {
+			continue
+		}
+		results = append(results, res)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results
+}
+
+// matchType checks if any of the ports has a given type
+func (res *Result) matchType(ports []EntryPort, t, kind string) bool {
+	for _, p := range ports {
+		if strings.EqualFold(p.Type, t) {
+			res.Score += 4
+			res.Matches = append(res.Matches, kind+" "+strings.ToUpper(p.Name)+" is "+p.Type)
+			return true
+		}
+	}
+	return false
+}
+
+// matchTerm scores a single term against all fields of the entry
+func (res *Result) matchTerm(t string) {
+	e := res.Entry
+	name := strings.ToLower(e.Name)
+	switch {
+	case name == t || strings.HasSuffix(name, "/"+t):
+		res.Score += 10
+		res.Matches = append(res.Matches, "name is "+t)
+	case strings.Contains(name, t):
+		res.Score += 5
+		res.Matches = append(res.Matches, "name contains "+t)
+	}
+	if containsWord(e.Description, t) {
+		res.Score += 3
+		res.Matches = append(res.Matches, "description mentions "+t)
+	}
+	ports := func(ports []EntryPort, kind string) {
+		for _, p := range ports {
+			label := kind + " " + strings.ToUpper(p.Name)
+			if strings.ToLower(p.Name) == t {
+				res.Score += 2
+				res.Matches = append(res.Matches, label)
+			}
+			if strings.ToLower(p.Type) == t {
+				res.Score += 2
+				res.Matches = append(res.Matches, label+" is "+p.Type)
+			}
+			if containsWord(p.Description, t) {
+				res.Score++
+				res.Matches = append(res.Matches, label+" mentions "+t)
+			}
+		}
+	}
+	ports(e.Inports, "inport")
+	ports(e.Outports, "outport")
+}
+
+// containsWord checks if a text contains a word starting with a given term
+func containsWord(text, term string) bool {
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) {
+		if strings.HasPrefix(w, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Stamp records a checksum and a modification time of the executable the
+// entry was introspected from
+func (entry *Entry) Stamp(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	sum, err := checksum(path)
+	if err != nil {
+		return err
+	}
+	entry.Checksum = sum
+	entry.Modified = info.ModTime().UnixNano()
+	return nil
+}
+
+// Stale checks if the executable has changed since the entry was stamped.
+// The checksum is compared only when the modification time differs.
+// Entries without a stamp are never stale
+func (entry Entry) Stale(path string) (bool, error) {
+	if entry.Checksum == "" {
+		return false, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().UnixNano() == entry.Modified {
+		return false, nil
+	}
+	sum, err := checksum(path)
+	if err != nil {
+		return false, err
+	}
+	return sum != entry.Checksum, nil
+}
+
+// checksum returns a hex-encoded SHA-256 sum of a file
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// StalePolicy defines what to do with the entries which executables have
+// changed since they were added to the library
+type StalePolicy string
+
+const (
+	// StaleRefresh re-introspects changed executables
+	StaleRefresh StalePolicy = "refresh"
+	// StaleWarn reports changed executables but uses the registered entries
+	StaleWarn StalePolicy = "warn"
+	// StaleFail refuses to use changed executables
+	StaleFail StalePolicy = "fail"
+)
+
+// ParseStalePolicy converts a string into a StalePolicy
+func ParseStalePolicy(s string) (StalePolicy, error) {
+	switch p := StalePolicy(s); p {
+	case StaleRefresh, StaleWarn, StaleFail:
+		return p, nil
+	}
+	return "", fmt.Errorf("Unknown stale policy %s (should be refresh, warn or fail)", s)
+}
+
+// Checked wraps a registrar and checks elementary entries for changed
+// executables when they are requested
+type Checked struct {
+	Registrar
+	Resolver *Resolver
+	Policy   StalePolicy
+	// Notify (if set) is called with a message about every changed executable
+	Notify func(message string)
+
+	mutex     sync.Mutex
+	refreshed map[string]Entry
+	warned    map[string]bool
+}
+
+// NewChecked is a Checked constructor
+func NewChecked(r Registrar, resolver *Resolver, policy StalePolicy) *Checked {
+	return &Checked{
+		Registrar: r,
+		Resolver:  resolver,
+		Policy:    policy,
+		refreshed: map[string]Entry{},
+		warned:    map[string]bool{},
+	}
+}
+
+// Get returns an entry checked against its executable
+func (c *Checked) Get(ref string) (Entry, error) {
+	entry, err := c.Registrar.Get(ref)
+	if err != nil || !entry.Elementary || entry.Checksum == "" {
+		return entry, err
+	}
+	path, err := c.Resolver.Resolve(entry.Executable)
+	if err != nil {
+		return entry, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if r, ok := c.refreshed[entry.Key()]; ok {
+		entry = r
+	}
+	stale, err := entry.Stale(path)
+	if err != nil || !stale {
+		return entry, err
+	}
+
+	switch c.Policy {
+	case StaleFail:
+		return entry, fmt.Errorf("Executable %s of component %s has changed since it was added to the library", path, entry.Key())
+	case StaleWarn:
+		if !c.warned[entry.Key()] {
+			c.warned[entry.Key()] = true
+			c.notify(fmt.Sprintf("Executable %s of component %s has changed since it was added to the library", path, entry.Key()))
+		}
+		return entry, nil
+	}
+
+	current, err := Introspect(path)
+	if err != nil {
+		return entry, err
+	}
+	current.Name = entry.Name
+	current.Version = entry.Version
+	current.Executable = entry.Executable
+	if err = current.Stamp(path); err != nil {
+		return entry, err
+	}
+	*current = current.normalized()
+	c.refreshed[entry.Key()] = *current
+	c.notify(fmt.Sprintf("Executable %s of component %s has changed, using re-introspected ports", path, entry.Key()))
+	return *current, nil
+}
+
+// Exists checks if an entry is available (and not refused as changed)
+func (c *Checked) Exists(ref string) bool {
+	_, err := c.Get(ref)
+	return err == nil
+}
+
+func (c *Checked) notify(message string) {
+	if c.Notify != nil {
+		c.Notify(message)
+	}
+}
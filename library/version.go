@@ -0,0 +1,102 @@
+package library
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SplitVersion splits a component reference (e.g. core/readfile@1.2) into
+// the component name and the pinned version (empty if not pinned)
+func SplitVersion(ref string) (string, string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// MatchVersion checks if a version satisfies a pin: either equals to it or
+// is a more specific version of it (1.2.3 matches 1.2). Empty pin matches
+// any version
+func MatchVersion(version, pin string) bool {
+	return pin == "" || version == pin || strings.HasPrefix(version, pin+".")
+}
+
+// CompareVersions compares dot-separated versions numerically where
+// possible. Returns -1, 0 or 1. Unversioned is lower than any version
+func CompareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && pa[i] != pb[i]:
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+	return 0
+}
+
+// Versions returns sorted versions of a component available in a registrar
+func Versions(r Registrar, name string) []string {
+	versions := []string{}
+	for _, e := range r.Find(name) {
+		if e.Name == name && e.Version != "" {
+			versions = append(versions, e.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+// Key returns the key of the entry in a library: name@version for the
+// versioned entries and just name otherwise
+func (entry Entry) Key() string {
+	if entry.Version == "" {
+		return entry.Name
+	}
+	return entry.Name + "@" + entry.Version
+}
+
+// latest picks the highest version among the entries matching a reference
+func latest(entries map[string]Entry, ref string) (Entry, bool) {
+	name, pin := SplitVersion(ref)
+	var result Entry
+	found := false
+	for _, e := range entries {
+		if e.Name != name || !MatchVersion(e.Version, pin) {
+			continue
+		}
+		if !found || CompareVersions(e.Version, result.Version) > 0 {
+			result = e
+			found = true
+		}
+	}
+	return result, found
+}
@@ -0,0 +1,390 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cascades-fbp/cascades/graph"
+	"github.com/cascades-fbp/cascades/library"
+)
+
+// Severity of the found issue
+type Severity string
+
+const (
+	// SeverityError is used for issues which make the graph unusable
+	SeverityError Severity = "error"
+	// SeverityWarning is used for suspicious but valid patterns
+	SeverityWarning Severity = "warning"
+)
+
+// Issue describes a single problem found in a graph
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Process  string   `json:"process,omitempty"`
+	Port     string   `json:"port,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	location := i.Process
+	if i.Port != "" {
+		location += "." + i.Port
+	}
+	if location != "" {
+		location += ": "
+	}
+	return fmt.Sprintf("%-7s %s%s [%s]", strings.ToUpper(string(i.Severity)), location, i.Message, i.Rule)
+}
+
+// HasErrors returns true if any of given issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// linter keeps the state of a single Lint run
+type linter struct {
+	g       *graph.Description
+	r       library.Registrar
+	entries map[string]library.Entry
+	issues  []Issue
+}
+
+// Lint checks a given graph for suspicious patterns. The registrar is
+// used for checking ports of the components and may be nil (in this case
+// only structural checks are performed)
+func Lint(g *graph.Description, r library.Registrar) []Issue {
+	l := &linter{
+		g:       g,
+		r:       r,
+		entries: map[string]library.Entry{},
+		issues:  []Issue{},
+	}
+	l.checkComponents()
+	l.checkConnections()
+	l.checkFanOut()
+	l.checkFanIn()
+	l.checkRequiredPorts()
+	l.checkDeadEnds()
+	l.checkIIPs()
+	l.checkExports()
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].Severity != l.issues[j].Severity {
+			return l.issues[i].Severity == SeverityError
+		}
+		return l.issues[i].Process < l.issues[j].Process
+	})
+	return l.issues
+}
+
+func (l *linter) report(severity Severity, rule, process, port, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{
+		Severity: severity,
+		Rule:     rule,
+		Process:  process,
+		Port:     port,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// processNames returns sorted process names for stable output
+func (l *linter) processNames() []string {
+	names := make([]string, 0, len(l.g.Processes))
+	for name := range l.g.Processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkComponents looks up components of all processes in the library
+func (l *linter) checkComponents() {
+	if l.r == nil {
+		return
+	}
+	for _, name := range l.processNames() {
+		p := l.g.Processes[name]
+		ref, err := p.ComponentRef()
+		if err != nil {
+			l.report(SeverityError, "version-pin", name, "", "%s", err.Error())
+			continue
+		}
+		entry, err := l.r.Get(ref)
+		if err != nil {
+			l.report(SeverityError, "unknown-component", name, "", "component %s not found in the library", ref)
+			continue
+		}
+		l.entries[name] = entry
+	}
+}
+
+// checkConnections reports connections between non-existing processes or ports
+func (l *linter) checkConnections() {
+	for _, c := range l.g.Connections {
+		if c.Tgt == nil {
+			l.report(SeverityError, "dangling-connection", "", "", "connection %s has no target", connectionString(c))
+			continue
+		}
+		l.checkEndpoint(c.Tgt, true)
+		if c.Src != nil {
+			l.checkEndpoint(c.Src, false)
+		}
+	}
+}
+
+func (l *linter) checkEndpoint(e *graph.Endpoint, isInput bool) {
+	if _, ok := l.g.Processes[e.Process]; !ok {
+		l.report(SeverityError, "dangling-connection", e.Process, e.Port, "process is not defined in the graph")
+		return
+	}
+	entry, ok := l.entries[e.Process]
+	if !ok {
+		return
+	}
+	port, found := l.findPort(entry, e.Port, isInput)
+	if !found {
+		l.report(SeverityError, "unknown-port", e.Process, e.Port, "component %s has no such %s", entry.Name, direction(isInput))
+		return
+	}
+	if e.Index != nil && !port.Addressable {
+		l.report(SeverityError, "unknown-port", e.Process, e.Port, "port is not an array port but index %v is used", *e.Index)
+	}
+}
+
+// checkFanOut reports outports connected to several targets. With PUSH
+// sockets packets are load-balanced between the targets instead of being
+// copied, which is rarely what the author of a graph expects
+func (l *linter) checkFanOut() {
+	targets := map[string][]string{}
+	sources := map[string]*graph.Endpoint{}
+	keys := []string{}
+	for _, c := range l.g.Connections {
+		if c.Src == nil || c.Tgt == nil {
+			continue
+		}
+		key := endpointKey(c.Src)
+		if _, ok := sources[key]; !ok {
+			sources[key] = c.Src
+			keys = append(keys, key)
+		}
+		targets[key] = append(targets[key], endpointKey(c.Tgt))
+	}
+	for _, key := range keys {
+		if len(targets[key]) < 2 {
+			continue
+		}
+		l.report(SeverityWarning, "fan-out", sources[key].Process, portName(sources[key]),
+			"output is connected to %v targets (%s), packets will be load-balanced rather than copied; use core/splitter to copy them",
+			len(targets[key]), strings.Join(targets[key], ", "))
+	}
+}
+
+// checkFanIn reports several sources connected to the same non-array inport
+func (l *linter) checkFanIn() {
+	sources := map[string]int{}
+	targets := map[string]*graph.Endpoint{}
+	keys := []string{}
+	for _, c := range l.g.Connections {
+		if c.Src == nil || c.Tgt == nil {
+			continue
+		}
+		key := endpointKey(c.Tgt)
+		if _, ok := targets[key]; !ok {
+			targets[key] = c.Tgt
+			keys = append(keys, key)
+		}
+		sources[key]++
+	}
+	for _, key := range keys {
+		if sources[key] < 2 {
+			continue
+		}
+		tgt := targets[key]
+		if entry, ok := l.entries[tgt.Process]; ok {
+			if p, found := l.findPort(entry, tgt.Port, true); found && p.Addressable {
+				continue
+			}
+		}
+		l.report(SeverityWarning, "fan-in", tgt.Process, portName(tgt),
+			"%v sources are connected to a non-array port, their packets will be interleaved", sources[key])
+	}
+}
+
+// checkRequiredPorts reports required ports which are neither connected nor exported
+func (l *linter) checkRequiredPorts() {
+	for _, name := range l.processNames() {
+		entry, ok := l.entries[name]
+		if !ok {
+			continue
+		}
+		for _, p := range entry.Inports {
+			if p.Required && !l.isConnected(name, p.Name, true) {
+				l.report(SeverityError, "unconnected-port", name, strings.ToUpper(p.Name), "required inport is not connected")
+			}
+		}
+		for _, p := range entry.Outports {
+			if p.Required && !l.isConnected(name, p.Name, false) {
+				l.report(SeverityError, "unconnected-port", name, strings.ToUpper(p.Name), "required outport is not connected")
+			}
+		}
+	}
+}
+
+// checkDeadEnds reports processes which have outports, but none of them is connected
+func (l *linter) checkDeadEnds() {
+	for _, name := range l.processNames() {
+		entry, ok := l.entries[name]
+		if !ok || len(entry.Outports) == 0 {
+			continue
+		}
+		connected := false
+		for _, p := range entry.Outports {
+			if p.Required || l.isConnected(name, p.Name, false) {
+				// required ones are already reported by checkRequiredPorts
+				connected = true
+				break
+			}
+		}
+		if !connected {
+			l.report(SeverityWarning, "dead-end", name, "", "output of the process goes nowhere")
+		}
+	}
+}
+
+// checkIIPs reports IIPs sent to the data ports. A port is considered to be
+// a data (not configuration) port if it is named IN, accepts any type or
+// also receives packets from other processes
+func (l *linter) checkIIPs() {
+	fed := map[string]bool{}
+	for _, c := range l.g.Connections {
+		if c.Src != nil && c.Tgt != nil {
+			fed[strings.ToLower(c.Tgt.Process+"."+c.Tgt.Port)] = true
+		}
+	}
+	for _, c := range l.g.Connections {
+		if c.Src != nil || c.Tgt == nil {
+			continue
+		}
+		process, port := c.Tgt.Process, c.Tgt.Port
+		if fed[strings.ToLower(process+"."+port)] {
+			l.report(SeverityWarning, "iip-data-port", process, port, "IIP '%s' is mixed with packets from other processes", c.Data)
+			continue
+		}
+		isData := strings.EqualFold(port, "in")
+		if entry, ok := l.entries[process]; ok {
+			if p, found := l.findPort(entry, port, true); found && p.Type == "all" {
+				isData = true
+			}
+		}
+		if isData {
+			l.report(SeverityWarning, "iip-data-port", process, port, "IIP '%s' is sent to a data port rather than a configuration port", c.Data)
+		}
+	}
+}
+
+// checkExports reports exported ports referring to non-existing processes or
+// ports (dangling) and ports exported while also wired inside the graph (unused)
+func (l *linter) checkExports() {
+	check := func(exports []graph.Export, isInput bool) {
+		seen := map[string]bool{}
+		for _, e := range exports {
+			if seen[e.Public] {
+				l.report(SeverityError, "duplicate-export", "", e.Public, "%s is exported more than once", direction(isInput))
+			}
+			seen[e.Public] = true
+			parts := strings.SplitN(e.Private, ".", 2)
+			if len(parts) != 2 {
+				l.report(SeverityError, "dangling-export", "", e.Public, "invalid private port %s", e.Private)
+				continue
+			}
+			if _, ok := l.g.Processes[parts[0]]; !ok {
+				l.report(SeverityError, "dangling-export", parts[0], parts[1], "exported as %s but process is not defined in the graph", e.Public)
+				continue
+			}
+			if l.isWired(parts[0], parts[1], isInput) {
+				l.report(SeverityWarning, "unused-export", parts[0], parts[1], "exported as %s but already wired inside the graph", e.Public)
+			}
+			entry, ok := l.entries[parts[0]]
+			if !ok {
+				continue
+			}
+			if _, found := l.findPort(entry, parts[1], isInput); !found {
+				l.report(SeverityError, "dangling-export", parts[0], parts[1], "exported as %s but component %s has no such %s", e.Public, entry.Name, direction(isInput))
+			}
+		}
+	}
+	check(l.g.Inports, true)
+	check(l.g.Outports, false)
+}
+
+// isWired checks if a given port of a process is connected to another
+// process (or receives an IIP) inside the graph
+func (l *linter) isWired(process, port string, isInput bool) bool {
+	for _, c := range l.g.Connections {
+		e := c.Src
+		if isInput {
+			e = c.Tgt
+		}
+		if e != nil && e.Process == process && strings.EqualFold(e.Port, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConnected checks if a given port of a process is connected or exported
+func (l *linter) isConnected(process, port string, isInput bool) bool {
+	if l.isWired(process, port, isInput) {
+		return true
+	}
+	exports := l.g.Outports
+	if isInput {
+		exports = l.g.Inports
+	}
+	for _, e := range exports {
+		if strings.EqualFold(e.Private, process+"."+port) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) findPort(entry library.Entry, port string, isInput bool) (library.EntryPort, bool) {
+	if isInput {
+		return entry.FindInport(strings.ToLower(port))
+	}
+	return entry.FindOutport(strings.ToLower(port))
+}
+
+func endpointKey(e *graph.Endpoint) string {
+	return e.Process + "." + portName(e)
+}
+
+func portName(e *graph.Endpoint) string {
+	if e.Index != nil {
+		return fmt.Sprintf("%s[%v]", e.Port, *e.Index)
+	}
+	return e.Port
+}
+
+func direction(isInput bool) string {
+	if isInput {
+		return "inport"
+	}
+	return "outport"
+}
+
+func connectionString(c graph.Connection) string {
+	if c.Src == nil {
+		return "'" + c.Data + "'"
+	}
+	return endpointKey(c.Src)
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LevelEnv is the name of environment variable the runtime uses to pass
+// the log level to the component processes
+const LevelEnv = "CASCADES_LOG_LEVEL"
+
+// Level of a log message
+type Level int
+
+const (
+	// LevelDebug is used for detailed output useful while debugging
+	LevelDebug Level = iota
+	// LevelInfo is used for regular output
+	LevelInfo
+	// LevelWarn is used for unexpected but recoverable situations
+	LevelWarn
+	// LevelError is used for failures
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Tag returns a prefix marking output lines of this level, e.g. [DEBUG]
+func (l Level) Tag() string {
+	return "[" + strings.ToUpper(l.String()) + "] "
+}
+
+// ParseLevel converts a given level name into a Level
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		name = "warn"
+	}
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("Unknown log level %s (should be debug, info, warn or error)", s)
+}
+
+// ParseLine extracts the level from a line of the process output tagged
+// with Level.Tag. Untagged lines get a given default level
+func ParseLine(line string, defaultLevel Level) (Level, string) {
+	if !strings.HasPrefix(line, "[") {
+		return defaultLevel, line
+	}
+	for i := LevelDebug; i <= LevelError; i++ {
+		if tag := i.Tag(); strings.HasPrefix(line, tag) {
+			return i, line[len(tag):]
+		}
+	}
+	return defaultLevel, line
+}
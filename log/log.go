@@ -3,17 +3,66 @@ package log
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/daviddengcn/go-colortext"
 )
 
+// Format of the multiplexed output
+type Format string
+
+const (
+	// FormatText is a human readable `name | line` output
+	FormatText Format = "text"
+	// FormatJSON outputs every line as a separate JSON object
+	FormatJSON Format = "json"
+)
+
+// ParseFormat converts a given string into a supported Format
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("Unsupported log format %s (should be text or json)", s)
+}
+
 // Factory is a factory of individual logs
 type Factory struct {
 	Logs    map[string]*Log
 	Padding int
 	Name    string
+	Format  Format
+	Colors  bool
+	Output  io.Writer
+
+	// Level is the minimal level of the lines to output, it can be
+	// overridden for individual processes in Levels
+	Level  Level
+	Levels map[string]Level
+
+	// Dir enables writing output of every process to its own
+	// <process>.log file in this directory
+	Dir        string
+	MaxSize    int64
+	MaxBackups int
+	files      map[string]*RotatingFile
+}
+
+// record is a single line of the JSON output
+type record struct {
+	Time    string `json:"time"`
+	Process string `json:"process"`
+	Stream  string `json:"stream"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
 }
 
 // Log represents a named colorful logger
@@ -22,6 +71,7 @@ type Log struct {
 	Color   ct.Color
 	IsError bool
 	Factory *Factory
+	File    io.Writer
 }
 
 var mx, sysMx sync.Mutex
@@ -42,58 +92,232 @@ var DefaultFactory *Factory
 func NewFactory() (of *Factory) {
 	of = new(Factory)
 	of.Logs = make(map[string]*Log)
+	of.files = make(map[string]*RotatingFile)
+	of.Level = LevelInfo
+	of.Levels = make(map[string]Level)
+	of.Format = FormatText
+	of.Colors = IsTerminal(os.Stdout)
+	of.Output = os.Stdout
 	return
 }
 
+// IsTerminal checks if a given file is a terminal (character device)
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 // Println writes a given string to logger's stream
 func (o *Log) Println(str string) {
 	o.Write([]byte(str))
 }
 
-// Write safely (using mutex) to a specific log
+// Write safely (using mutex) to a specific log. The process log file gets
+// all lines, lines below the level of the process are skipped in the
+// multiplexed output
 func (o *Log) Write(b []byte) (num int, err error) {
 	mx.Lock()
 	defer mx.Unlock()
+	defaultLevel := LevelInfo
+	if o.IsError {
+		defaultLevel = LevelError
+	}
+	threshold := o.Factory.levelOf(o.Name)
 	scanner := bufio.NewScanner(bytes.NewReader(b))
 	for scanner.Scan() {
+		level, message := ParseLine(scanner.Text(), defaultLevel)
+		if o.File != nil {
+			o.Factory.writeFileLine(o.File, o.Name, o.IsError, level, message)
+		}
+		if level < threshold {
+			continue
+		}
+		if o.Factory.Format == FormatJSON {
+			o.Factory.writeRecord(o.Factory.Output, o.Name, o.IsError, level, message)
+			continue
+		}
 		formatter := fmt.Sprintf("%%-%ds | ", o.Factory.Padding)
-		ct.ChangeColor(o.Color, true, ct.None, false)
-		fmt.Printf(formatter, o.Name)
-		if o.IsError {
-			ct.ChangeColor(ct.Red, true, ct.None, true)
+		o.Factory.changeColor(o.Color, true, ct.None, false)
+		fmt.Fprintf(o.Factory.Output, formatter, o.Name)
+		if o.IsError || level == LevelError {
+			o.Factory.changeColor(ct.Red, true, ct.None, true)
 		} else {
-			ct.ResetColor()
+			o.Factory.resetColor()
 		}
-		fmt.Println(scanner.Text())
-		ct.ResetColor()
+		fmt.Fprintln(o.Factory.Output, scanner.Text())
+		o.Factory.resetColor()
 	}
 	num = len(b)
 	return
 }
 
+// LevelOf returns the minimal level of output lines of a given process
+func (of *Factory) LevelOf(name string) Level {
+	mx.Lock()
+	defer mx.Unlock()
+	return of.levelOf(name)
+}
+
+// SetLevel overrides the minimal level of output lines of a given process.
+// It is safe to call while processes are writing their output
+func (of *Factory) SetLevel(name string, level Level) {
+	mx.Lock()
+	defer mx.Unlock()
+	of.Levels[name] = level
+}
+
+func (of *Factory) levelOf(name string) Level {
+	if l, ok := of.Levels[name]; ok {
+		return l
+	}
+	return of.Level
+}
+
+// writeFileLine writes a single line of output to a process log file
+// (timestamped, either in text or JSON format)
+func (of *Factory) writeFileLine(w io.Writer, name string, isError bool, level Level, message string) {
+	if of.Format == FormatJSON {
+		of.writeRecord(w, name, isError, level, message)
+		return
+	}
+	fmt.Fprintf(w, "%s %s %-5s %s\n", time.Now().Format(time.RFC3339Nano), streamName(isError), level.String(), message)
+}
+
+// writeRecord writes a single line of output as a JSON object
+func (of *Factory) writeRecord(w io.Writer, name string, isError bool, level Level, message string) {
+	data, err := json.Marshal(record{
+		Time:    time.Now().Format(time.RFC3339Nano),
+		Process: name,
+		Stream:  streamName(isError),
+		Level:   level.String(),
+		Message: message,
+	})
+	if err != nil {
+		return
+	}
+	w.Write(append(data, '\n'))
+}
+
+func streamName(isError bool) string {
+	if isError {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// changeColor changes color of the terminal output if colors are enabled
+// (go-colortext writes escape sequences directly to stdout)
+func (of *Factory) changeColor(fg ct.Color, fgBright bool, bg ct.Color, bgBright bool) {
+	if of.Colors {
+		ct.ChangeColor(fg, fgBright, bg, bgBright)
+	}
+}
+
+// resetColor resets color of the terminal output if colors are enabled
+func (of *Factory) resetColor() {
+	if of.Colors {
+		ct.ResetColor()
+	}
+}
+
 // CreateLog create a new Log structure
 func (of *Factory) CreateLog(name string, index int, isError bool) *Log {
-	of.Logs[name] = &Log{name, colors[index%len(colors)], isError, of}
-	return of.Logs[name]
+	l := &Log{
+		Name:    name,
+		Color:   colors[index%len(colors)],
+		IsError: isError,
+		Factory: of,
+	}
+	if of.Dir != "" {
+		f, err := of.openFile(name)
+		if err != nil {
+			of.ErrorOutput(fmt.Sprintf("Failed to open log file for %s: %s", name, err.Error()))
+		} else {
+			l.File = f
+		}
+	}
+	of.Logs[name] = l
+	return l
+}
+
+// openFile returns a log file of a given process (stdout and stderr of
+// a process, also restarted one, share the same file)
+func (of *Factory) openFile(name string) (*RotatingFile, error) {
+	mx.Lock()
+	defer mx.Unlock()
+	if f, ok := of.files[name]; ok {
+		return f, nil
+	}
+	if err := os.MkdirAll(of.Dir, 0755); err != nil {
+		return nil, err
+	}
+	filename := strings.Replace(name, string(filepath.Separator), "_", -1) + ".log"
+	f, err := OpenRotatingFile(filepath.Join(of.Dir, filename), of.MaxSize, of.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	of.files[name] = f
+	return f, nil
+}
+
+// Close closes all process log files
+func (of *Factory) Close() {
+	mx.Lock()
+	defer mx.Unlock()
+	for name, f := range of.files {
+		f.Close()
+		delete(of.files, name)
+	}
 }
 
 // SystemOutput prints a given string safely (using mutex) to output (from system's name)
 func (of *Factory) SystemOutput(str string) {
-	sysMx.Lock()
-	defer sysMx.Unlock()
-	ct.ChangeColor(ct.White, true, ct.None, false)
-	formatter := fmt.Sprintf("%%-%ds | ", of.Padding)
-	fmt.Printf(formatter, of.Name)
-	ct.ResetColor()
-	fmt.Println(str)
-	ct.ResetColor()
+	of.systemOutput(LevelInfo, str)
+}
+
+// DebugOutput prints a given debug message (from system's name)
+func (of *Factory) DebugOutput(str string) {
+	of.systemOutput(LevelDebug, str)
+}
+
+// WarnOutput prints a given warning (from system's name)
+func (of *Factory) WarnOutput(str string) {
+	of.systemOutput(LevelWarn, str)
 }
 
 // ErrorOutput writes safely (using mutex) to error output (from system's name)
 func (of *Factory) ErrorOutput(str string) {
 	sysMx.Lock()
 	defer sysMx.Unlock()
-	fmt.Printf("ERROR: %s\n", str)
+	if of.Format == FormatJSON {
+		of.writeRecord(of.Output, of.Name, true, LevelError, str)
+		return
+	}
+	fmt.Fprintf(of.Output, "ERROR: %s\n", str)
+}
+
+func (of *Factory) systemOutput(level Level, str string) {
+	if level < of.Level {
+		return
+	}
+	sysMx.Lock()
+	defer sysMx.Unlock()
+	if of.Format == FormatJSON {
+		of.writeRecord(of.Output, of.Name, false, level, str)
+		return
+	}
+	of.changeColor(ct.White, true, ct.None, false)
+	formatter := fmt.Sprintf("%%-%ds | ", of.Padding)
+	fmt.Fprintf(of.Output, formatter, of.Name)
+	of.resetColor()
+	if level != LevelInfo {
+		str = level.Tag() + str
+	}
+	fmt.Fprintln(of.Output, str)
+	of.resetColor()
 }
 
 func init() {
@@ -111,3 +335,13 @@ func SystemOutput(str string) {
 func ErrorOutput(str string) {
 	DefaultFactory.ErrorOutput(str)
 }
+
+// DebugOutput writes debug message using default factory
+func DebugOutput(str string) {
+	DefaultFactory.DebugOutput(str)
+}
+
+// WarnOutput writes warning using default factory
+func WarnOutput(str string) {
+	DefaultFactory.WarnOutput(str)
+}
@@ -5,9 +5,19 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 )
 
+// ReconnectGraceEnv is the name of environment variable with a duration the
+// components should wait for their peers to reconnect before treating a port
+// as closed (set by the runtime when the network may be reconfigured)
+const ReconnectGraceEnv = "CASCADES_RECONNECT_GRACE"
+
+// LogLevelMetadata is the key of process metadata defining the log level
+// of the process (overrides the default level of the network)
+const LogLevelMetadata = "loglevel"
+
 // Env is a map of key/values to pass as env variables to a process
 type Env map[string]string
 
@@ -22,13 +32,15 @@ type Process struct {
 	Stderr      io.Writer
 	Root        string
 
-	cmd *exec.Cmd
+	cmd  *exec.Cmd
+	done chan bool
 }
 
 // ProcessIIP is a model of IIP (sent when processes started)
 type ProcessIIP struct {
 	Payload string
 	Socket  string
+	Process string
 }
 
 // NewProcess is a process constructor
@@ -42,6 +54,7 @@ func NewProcess(executable string) (p *Process) {
 	p.Stdout = os.Stdout
 	p.Stderr = os.Stderr
 	p.Root, _ = os.Getwd()
+	p.done = make(chan bool)
 	return
 }
 
@@ -58,6 +71,13 @@ func (p *Process) Pid() int {
 // Wait makes process command's wait
 func (p *Process) Wait() {
 	p.cmd.Wait()
+	close(p.done)
+}
+
+// Done returns a channel which is closed when the process exits
+// (after Wait returns)
+func (p *Process) Done() <-chan bool {
+	return p.done
 }
 
 // Command returns a process command to execute
@@ -67,8 +87,15 @@ func (p *Process) Command() string {
 
 // Arguments returns arguments string for a command
 func (p *Process) Arguments() string {
+	keys := make([]string, 0, len(p.Args))
+	for k := range p.Args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	args := ""
-	for k, v := range p.Args {
+	for _, k := range keys {
+		v := p.Args[k]
 		if v == "" {
 			args = fmt.Sprintf("%s%s ", args, k)
 		} else {
@@ -0,0 +1,166 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cascades-fbp/cascades/graph"
+	"github.com/cascades-fbp/cascades/log"
+)
+
+// stopTimeout is how long a process is given to exit after SIGTERM
+// before it is killed during reconfiguration
+const stopTimeout = 3 * time.Second
+
+// reloadMutex serializes reconfigurations of the running network and guards
+// the current graph (with its directories and files) read by accessors
+var reloadMutex sync.RWMutex
+
+//
+// Reload loads a modified graph from a given file and applies it to the
+// running network
+//
+func (r *Runtime) Reload(graphfile string) error {
+	g, dirs, files, err := r.loadFlatGraph(graphfile)
+	if err != nil {
+		return err
+	}
+	return r.apply(g, dirs, files, nil)
+}
+
+//
+// Apply reconfigures the running network according to a given flattened
+// graph: removed processes are stopped, new ones are started and processes
+// which component or connections changed are restarted. Untouched processes
+// keep running with their state. IIPs are sent only to the started processes
+// and to the processes which IIPs changed
+//
+func (r *Runtime) Apply(g *graph.Description, dirs map[string]string) error {
+	return r.apply(g, dirs, nil, nil)
+}
+
+//
+// Restart stops and starts again given processes of the running network
+// (e.g. when their executables have been rebuilt) and resends their IIPs
+//
+func (r *Runtime) Restart(names ...string) error {
+	return r.apply(nil, nil, nil, names)
+}
+
+// apply reconfigures the network to a given graph (the current one if nil)
+// restarting given processes. Graph files are updated if given
+func (r *Runtime) apply(g *graph.Description, dirs map[string]string, files []string, restart []string) error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	if g == nil {
+		g, dirs = r.graph, r.dirs
+	}
+	for _, name := range restart {
+		if _, ok := g.Processes[name]; !ok {
+			return fmt.Errorf("Process %s not found in the network", name)
+		}
+	}
+	if files != nil {
+		// files are watched even if the graph fails to apply or is unchanged
+		r.files = files
+	}
+
+	diff := graph.Compare(r.graph, g)
+	if diff.Empty() && len(restart) == 0 {
+		log.SystemOutput("Graph has not changed, nothing to reload")
+		return nil
+	}
+
+	net, err := r.prepareNetwork(g, dirs, r.sockets)
+	if err != nil {
+		return err
+	}
+
+	// Keep the network alive while processes are being replaced
+	procWaitGroup.Add(1)
+	defer procWaitGroup.Done()
+
+	shutdownMutex.Lock()
+	r.reloading = true
+	changed := map[string]bool{}
+	for _, c := range diff.Processes {
+		changed[c.Name] = true
+	}
+	for _, name := range restart {
+		changed[name] = true
+	}
+	stop := map[string]*Process{}
+	start := []string{}
+	for name, ps := range r.processes {
+		next, ok := net.processes[name]
+		if !ok || changed[name] || next.Command() != ps.Command() {
+			stop[name] = ps
+			delete(r.processes, name)
+		}
+	}
+	for name := range net.processes {
+		if _, ok := r.processes[name]; !ok {
+			start = append(start, name)
+		}
+	}
+	shutdownMutex.Unlock()
+
+	defer func() {
+		shutdownMutex.Lock()
+		r.reloading = false
+		shutdownMutex.Unlock()
+	}()
+
+	// Stop removed and changed processes
+	for name, ps := range stop {
+		log.SystemOutput(fmt.Sprintf("Stopping %s", name))
+		ps.Signal(syscall.SIGTERM)
+	}
+	for name, ps := range stop {
+		select {
+		case <-ps.Done():
+		case <-time.After(stopTimeout):
+			log.WarnOutput(fmt.Sprintf("sending SIGKILL to %s", name))
+			ps.Signal(syscall.SIGKILL)
+			<-ps.Done()
+		}
+	}
+
+	// Start new and changed processes
+	sort.Strings(start)
+	for _, name := range start {
+		log.SystemOutput(fmt.Sprintf("Starting %s", name))
+		shutdownMutex.Lock()
+		r.processes[name] = net.processes[name]
+		shutdownMutex.Unlock()
+		r.startProcess(name, net.processes[name])
+	}
+
+	r.graph = g
+	r.dirs = dirs
+	r.sockets = net.sockets
+	r.iips = net.iips
+
+	// Resend IIPs to started processes and processes which IIPs changed
+	resend := map[string]bool{}
+	for _, name := range start {
+		resend[name] = true
+	}
+	for _, c := range diff.Initials {
+		resend[c.Target.Process] = true
+	}
+	iips := []ProcessIIP{}
+	for _, iip := range net.iips {
+		if resend[iip.Process] {
+			iips = append(iips, iip)
+		}
+	}
+	r.sendIIPs(iips)
+
+	log.SystemOutput(fmt.Sprintf("Reloaded: %v stopped, %v started", len(stop), len(start)))
+	return nil
+}
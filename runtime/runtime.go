@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -24,9 +25,19 @@ var (
 type Runtime struct {
 	registrar      library.Registrar
 	initialTCPPort uint
+	currentTCPPort uint
 	graph          *graph.Description
+	dirs           map[string]string
+	files          []string
 	processes      map[string]*Process
+	sockets        map[string]string
 	iips           []ProcessIIP
+	started        int
+	reloading      bool
+	Resolver       *library.Resolver
+	ReconnectGrace time.Duration
+	LogLevel       log.Level
+	LogLevels      map[string]log.Level
 	Done           chan bool
 	Debug          bool
 }
@@ -38,8 +49,14 @@ func NewRuntime(registrar library.Registrar, initialTCPPort uint) *Runtime {
 	r := &Runtime{
 		registrar:      registrar,
 		initialTCPPort: initialTCPPort,
+		currentTCPPort: initialTCPPort,
+		dirs:           map[string]string{},
 		processes:      map[string]*Process{},
+		sockets:        map[string]string{},
 		iips:           []ProcessIIP{},
+		Resolver:       library.NewResolver(""),
+		LogLevel:       log.LevelInfo,
+		LogLevels:      map[string]log.Level{},
 		Done:           make(chan bool),
 		Debug:          false,
 	}
@@ -50,44 +67,81 @@ func NewRuntime(registrar library.Registrar, initialTCPPort uint) *Runtime {
 // LoadGraph loads graph definition in supported format from a given file path
 //
 func (r *Runtime) LoadGraph(graphfile string) error {
-	var err error
-	if r.graph, err = loadGraph(graphfile); err != nil {
+	g, dirs, files, err := r.loadFlatGraph(graphfile)
+	if err != nil {
 		return err
 	}
-	err = r.flattenGraph(r.graph)
-	return err
+	r.graph = g
+	r.dirs = dirs
+	r.files = files
+	return nil
+}
+
+//
+// Loads a graph from a given file and flattens it. Returns the graph,
+// directories of the graph files every process comes from and paths of
+// all loaded graph files (the given one and its subgraphs)
+//
+func (r *Runtime) loadFlatGraph(graphfile string) (*graph.Description, map[string]string, []string, error) {
+	g, err := loadGraph(graphfile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dir, err := graphDir(graphfile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dirs := map[string]string{}
+	for name := range g.Processes {
+		dirs[name] = dir
+	}
+	files := []string{filepath.Join(dir, filepath.Base(graphfile))}
+	subgraphs, err := r.flattenGraph(g, dirs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return g, dirs, append(files, subgraphs...), nil
 }
 
 //
-// Validates the graph against library and flattens it (unwraps subgraphs)
+// Validates the graph against library and flattens it (unwraps subgraphs).
+// Returns paths of the loaded subgraph files
 //
-func (r *Runtime) flattenGraph(g *graph.Description) error {
+func (r *Runtime) flattenGraph(g *graph.Description, dirs map[string]string) ([]string, error) {
 	// copy processes map to interate over
 	hasSubgraphs := false
+	files := []string{}
 	processes := g.Processes
 	for name, process := range processes {
 		// Check if known component
-		e, err := r.registrar.Get(process.Component)
+		e, err := r.componentEntry(process)
 		if err != nil {
-			return fmt.Errorf("Component %s not found in the library", process.Component)
+			return nil, err
 		}
 
 		// Check if subgraph
-		if !strings.HasSuffix(e.Executable, ".fbp") && !strings.HasSuffix(e.Executable, ".json") {
+		if !graph.IsGraphFile(e.Executable) {
 			continue
 		}
 
-		// Load subgraph & "unwrap" it
+		// Load subgraph (relative to the parent graph) & "unwrap" it
 		hasSubgraphs = true
-		subgraph, err := loadGraph(e.Executable)
+		path, err := r.Resolver.Resolve(e.Executable, dirs[name])
 		if err != nil {
-			return err
+			return nil, err
 		}
+		subgraph, err := loadGraph(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
 
 		// Replace subgraph with its processes/connections in the graph
 		delete(g.Processes, name)
+		delete(dirs, name)
 		for n, p := range subgraph.Processes {
 			g.Processes[name+n] = p
+			dirs[name+n] = filepath.Dir(path)
 		}
 		for _, c := range subgraph.Connections {
 			if c.Src != nil {
@@ -124,19 +178,80 @@ func (r *Runtime) flattenGraph(g *graph.Description) error {
 	}
 
 	if hasSubgraphs {
-		err := r.flattenGraph(g)
+		subgraphs, err := r.flattenGraph(g, dirs)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		files = append(files, subgraphs...)
 	}
 
-	return nil
+	return files, nil
+}
+
+//
+// Returns the library entry of a process component. Refuses to use other
+// versions of the component if the version is pinned
+//
+func (r *Runtime) componentEntry(p graph.Process) (library.Entry, error) {
+	ref, err := p.ComponentRef()
+	if err != nil {
+		return library.Entry{}, err
+	}
+	entry, err := r.registrar.Get(ref)
+	if err == nil {
+		return entry, nil
+	}
+	if err != library.ErrNotFound {
+		return entry, err
+	}
+	name, pin := library.SplitVersion(ref)
+	if versions := library.Versions(r.registrar, name); pin != "" && len(versions) > 0 {
+		return entry, fmt.Errorf("Component %s has no version matching %s (available: %s)", name, pin, strings.Join(versions, ", "))
+	}
+	return entry, fmt.Errorf("Component %s not found in the library", ref)
+}
+
+//
+// GraphFiles returns paths of the graph file and all its subgraphs the
+// current network is loaded from
+//
+func (r *Runtime) GraphFiles() []string {
+	reloadMutex.RLock()
+	defer reloadMutex.RUnlock()
+	return append([]string{}, r.files...)
+}
+
+//
+// Executables returns resolved paths of the component executables used
+// by the current network with the names of processes using them
+//
+func (r *Runtime) Executables() (map[string][]string, error) {
+	reloadMutex.RLock()
+	defer reloadMutex.RUnlock()
+	executables := map[string][]string{}
+	for name, p := range r.graph.Processes {
+		entry, err := r.componentEntry(p)
+		if err != nil {
+			return nil, err
+		}
+		path, err := r.Resolver.Resolve(entry.Executable, r.dirs[name])
+		if err != nil {
+			return nil, err
+		}
+		executables[path] = append(executables[path], name)
+	}
+	for _, names := range executables {
+		sort.Strings(names)
+	}
+	return executables, nil
 }
 
 //
 // PrintGraph print the current graph for debug purposes
 //
 func (r *Runtime) PrintGraph() {
+	reloadMutex.RLock()
+	defer reloadMutex.RUnlock()
 	fmt.Println("--------- Properties ----------")
 	for k, v := range r.graph.Properties {
 		fmt.Printf("%s: %s\n", k, v)
@@ -161,84 +276,100 @@ func (r *Runtime) PrintGraph() {
 }
 
 //
-// Prepare processes for start using graph definition
+// network is a set of processes (with their port arguments) and IIPs
+// prepared for execution from a flattened graph
 //
-func (r *Runtime) prepareProcesses() error {
+type network struct {
+	processes map[string]*Process
+	sockets   map[string]string
+	iips      []ProcessIIP
+}
+
+//
+// Prepare processes for start using graph definition. Addresses of the
+// given previous sockets are reused for the same ports if possible, so the
+// processes which connections did not change get the same arguments
+//
+func (r *Runtime) prepareNetwork(g *graph.Description, dirs map[string]string, previous map[string]string) (*network, error) {
+	net := &network{
+		processes: map[string]*Process{},
+		sockets:   map[string]string{},
+		iips:      []ProcessIIP{},
+	}
+
 	// Create process structures for execution
 	nameLength := log.DefaultFactory.Padding
-	for name, p := range r.graph.Processes {
-		entry, err := r.registrar.Get(p.Component)
+	for name, p := range g.Processes {
+		entry, err := r.componentEntry(p)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		r.processes[name] = NewProcess(entry.Executable)
-		if r.Debug {
-			r.processes[name].Args["--debug"] = ""
+		executable, err := r.Resolver.Resolve(entry.Executable, dirs[name])
+		if err != nil {
+			return nil, err
+		}
+		net.processes[name] = NewProcess(executable)
+		level, err := r.processLevel(name, p)
+		if err != nil {
+			return nil, err
+		}
+		net.processes[name].Env[log.LevelEnv] = level.String()
+		if level == log.LevelDebug {
+			net.processes[name].Args["--debug"] = ""
+		}
+		log.DefaultFactory.SetLevel(name, level)
+		if r.ReconnectGrace > 0 {
+			net.processes[name].Env[ReconnectGraceEnv] = r.ReconnectGrace.String()
 		}
 		if len(name) > nameLength {
 			nameLength = len(name)
 		}
 	}
-	// Create ZMQ sockets for each unique port
-	var currentPort = r.initialTCPPort
-	var (
-		endpoint, srcEndpoint, tgtEndpoint string
-		index, srcIndex, tgtIndex          int
-	)
-	sockets := map[string]string{}
-	for _, c := range r.graph.Connections {
-		if c.Src == nil {
-			iip := ProcessIIP{
-				Payload: c.Data,
-			}
-			index = 0
-			if c.Tgt.Index != nil {
-				index = *c.Tgt.Index
-			}
-			endpoint = fmt.Sprintf("%s.%s.%v", c.Tgt.Process, c.Tgt.Port, index)
-			if s, ok := sockets[endpoint]; ok {
-				iip.Socket = s
-			} else {
-				s = fmt.Sprintf("tcp://127.0.0.1:%v", currentPort)
-				currentPort++
-				iip.Socket = s
-				sockets[endpoint] = s
-			}
-			r.iips = append(r.iips, iip)
 
-		} else {
-			srcIndex = 0
-			tgtIndex = 0
-			if c.Src.Index != nil {
-				srcIndex = *c.Src.Index
-			}
-			if c.Tgt.Index != nil {
-				tgtIndex = *c.Tgt.Index
-			}
-			srcEndpoint = fmt.Sprintf("%s.%s.%v", c.Src.Process, c.Src.Port, srcIndex)
-			tgtEndpoint = fmt.Sprintf("%s.%s.%v", c.Tgt.Process, c.Tgt.Port, tgtIndex)
+	// Group connected endpoints: every group shares a single ZMQ socket
+	groups := newEndpointGroups()
+	for _, c := range g.Connections {
+		tgtEndpoint := socketEndpoint(c.Tgt)
+		groups.add(tgtEndpoint)
+		if c.Src != nil {
+			groups.union(socketEndpoint(c.Src), tgtEndpoint)
+		}
+	}
 
-			if s, ok := sockets[srcEndpoint]; ok {
-				if _, ok := sockets[tgtEndpoint]; !ok {
-					sockets[tgtEndpoint] = s
-				}
-			} else {
-				if s, ok := sockets[tgtEndpoint]; ok {
-					sockets[srcEndpoint] = s
-				} else {
-					s = fmt.Sprintf("tcp://127.0.0.1:%v", currentPort)
-					currentPort++
-					sockets[srcEndpoint] = s
-					sockets[tgtEndpoint] = s
-				}
+	// Assign a socket for each group reusing previous addresses if possible
+	used := map[string]bool{}
+	for _, members := range groups.list() {
+		address := ""
+		for _, m := range members {
+			if s, ok := previous[m]; ok && !used[s] {
+				address = s
+				break
 			}
 		}
+		if address == "" {
+			address = fmt.Sprintf("tcp://127.0.0.1:%v", r.currentTCPPort)
+			r.currentTCPPort++
+		}
+		used[address] = true
+		for _, m := range members {
+			net.sockets[m] = address
+		}
+	}
+
+	for _, c := range g.Connections {
+		if c.Src == nil {
+			net.iips = append(net.iips, ProcessIIP{
+				Payload: c.Data,
+				Socket:  net.sockets[socketEndpoint(c.Tgt)],
+				Process: c.Tgt.Process,
+			})
+		}
 	}
 
 	// Solves: https://github.com/cascades-fbp/cascades/issues/17
-	keys := make([]string, len(sockets))
+	keys := make([]string, len(net.sockets))
 	i := 0
-	for k := range sockets {
+	for k := range net.sockets {
 		keys[i] = k
 		i++
 	}
@@ -250,16 +381,16 @@ func (r *Runtime) prepareProcesses() error {
 		parts := strings.SplitN(n, ".", 3)
 		k := parts[0] + "." + parts[1]
 		if _, ok := arguments[k]; ok {
-			arguments[k] = append(arguments[k], sockets[n])
+			arguments[k] = append(arguments[k], net.sockets[n])
 		} else {
-			arguments[k] = []string{sockets[n]}
+			arguments[k] = []string{net.sockets[n]}
 		}
 
 	}
 
 	if r.Debug {
 		fmt.Println("------------ IIPs -------------")
-		for _, d := range r.iips {
+		for _, d := range net.iips {
 			fmt.Printf("'%v' -> %v\n", string(d.Payload), d.Socket)
 		}
 		fmt.Println("-------------------------------")
@@ -268,7 +399,7 @@ func (r *Runtime) prepareProcesses() error {
 	// Add sockets to component CLI arguments
 	for n, s := range arguments {
 		parts := strings.SplitN(n, ".", 2)
-		r.processes[parts[0]].Args["--port."+strings.ToLower(parts[1])] = strings.Join(s, ",")
+		net.processes[parts[0]].Args["--port."+strings.ToLower(parts[1])] = strings.Join(s, ",")
 		if r.Debug {
 			fmt.Println(n, s)
 		}
@@ -276,7 +407,7 @@ func (r *Runtime) prepareProcesses() error {
 
 	if r.Debug {
 		fmt.Println("--------- Executables ---------")
-		for n, p := range r.processes {
+		for n, p := range net.processes {
 			fmt.Printf("%s: %#v %#v\n", n, p.Executable, p.Arguments())
 		}
 		fmt.Println("-------------------------------")
@@ -284,19 +415,43 @@ func (r *Runtime) prepareProcesses() error {
 
 	log.DefaultFactory.Padding = nameLength
 
-	return nil
+	return net, nil
+}
+
+//
+// Returns the log level of a process: the one explicitly set for the
+// process in LogLevels, defined in the process metadata or the default one
+//
+func (r *Runtime) processLevel(name string, p graph.Process) (log.Level, error) {
+	if level, ok := r.LogLevels[name]; ok {
+		return level, nil
+	}
+	if value, ok := p.Metadata[LogLevelMetadata]; ok {
+		level, err := log.ParseLevel(value)
+		if err != nil {
+			return level, fmt.Errorf("Process %s: %s", name, err.Error())
+		}
+		return level, nil
+	}
+	return r.LogLevel, nil
 }
 
 //
 // Start the network based on the current graph
 //
 func (r *Runtime) Start(dry bool) {
-	err := r.prepareProcesses()
+	reloadMutex.RLock()
+	g, dirs := r.graph, r.dirs
+	reloadMutex.RUnlock()
+	net, err := r.prepareNetwork(g, dirs, nil)
 	if err != nil {
 		log.ErrorOutput("Failed to create a process: " + err.Error())
 		r.Done <- true
 		return
 	}
+	r.processes = net.processes
+	r.sockets = net.sockets
+	r.iips = net.iips
 
 	if len(r.processes) == 0 {
 		log.SystemOutput("No processes to start")
@@ -310,50 +465,68 @@ func (r *Runtime) Start(dry bool) {
 	}
 
 	log.SystemOutput("Starting processes...")
-	idx := 0
 	for name, ps := range r.processes {
-		shutdownMutex.Lock()
-		procWaitGroup.Add(1)
+		r.startProcess(name, ps)
+	}
 
-		ps.Stdin = nil
-		ps.Stdout = log.DefaultFactory.CreateLog(name, idx, false)
-		ps.Stderr = log.DefaultFactory.CreateLog(name, idx, true)
-		ps.Start()
+	r.Activate()
 
-		go func(name string, ps *Process) {
-			ps.Wait()
-			procWaitGroup.Done()
-			delete(r.processes, name)
-			fmt.Fprintln(ps.Stdout, "Stopped")
-
-			// Shutdown when no processes left, otherwise network should collapse
-			// in a cascade way...
-			//if !ps.cmd.ProcessState.Success() || len(r.processes) == 0 {
-			if len(r.processes) == 0 {
-				fmt.Fprintln(ps.Stdout, "I was the last running process. Calling runtime to SHUTDOWN")
-				r.Shutdown()
-			}
+	procWaitGroup.Wait()
+}
 
-		}(name, ps)
+//
+// Start a single process of the network and watch for its exit
+//
+func (r *Runtime) startProcess(name string, ps *Process) {
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
 
-		shutdownMutex.Unlock()
+	procWaitGroup.Add(1)
 
-		idx++
-	}
+	ps.Stdin = nil
+	ps.Stdout = log.DefaultFactory.CreateLog(name, r.started, false)
+	ps.Stderr = log.DefaultFactory.CreateLog(name, r.started, true)
+	ps.Start()
+	r.started++
 
-	r.Activate()
+	go func() {
+		ps.Wait()
+		procWaitGroup.Done()
 
-	procWaitGroup.Wait()
+		shutdownMutex.Lock()
+		if r.processes[name] == ps {
+			delete(r.processes, name)
+		}
+		last := len(r.processes) == 0 && !r.reloading
+		shutdownMutex.Unlock()
+
+		fmt.Fprintln(ps.Stdout, "Stopped")
+
+		// Shutdown when no processes left, otherwise network should collapse
+		// in a cascade way...
+		//if !ps.cmd.ProcessState.Success() || len(r.processes) == 0 {
+		if last {
+			fmt.Fprintln(ps.Stdout, "I was the last running process. Calling runtime to SHUTDOWN")
+			r.Shutdown()
+		}
+	}()
 }
 
 //
 // Activate network by sending out all IIPs
 //
 func (r *Runtime) Activate() {
-	if len(r.iips) > 0 {
+	r.sendIIPs(r.iips)
+}
+
+//
+// Send given IIPs to their sockets
+//
+func (r *Runtime) sendIIPs(iips []ProcessIIP) {
+	if len(iips) > 0 {
 		// Connect to ports of IIP (so the components can resume execution)
-		senders := make([]*zmq.Socket, len(r.iips))
-		for i, iip := range r.iips {
+		senders := make([]*zmq.Socket, len(iips))
+		for i, iip := range iips {
 			senders[i], _ = zmq.NewSocket(zmq.PUSH)
 			senders[i].Connect(iip.Socket)
 		}
@@ -369,7 +542,7 @@ func (r *Runtime) Activate() {
 
 		// Send IIPs out!
 		log.SystemOutput("Activating processes by sending IIPs...")
-		for i, iip := range r.iips {
+		for i, iip := range iips {
 			log.SystemOutput(fmt.Sprintf("Sending '%s' to socket '%s'", iip.Payload, iip.Socket))
 			senders[i].SendMessageDontwait(NewPacket([]byte(iip.Payload)))
 		}
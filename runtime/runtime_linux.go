@@ -27,7 +27,7 @@ func (r *Runtime) Shutdown() {
 	go func() {
 		time.Sleep(3 * time.Second)
 		for name, ps := range r.processes {
-			log.SystemOutput(fmt.Sprintf("sending SIGKILL to %s", name))
+			log.WarnOutput(fmt.Sprintf("sending SIGKILL to %s", name))
 			ps.Signal(syscall.SIGKILL)
 		}
 		r.Done <- true
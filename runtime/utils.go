@@ -2,27 +2,77 @@ package runtime
 
 import (
 	"fmt"
-	"io/ioutil"
-	"strings"
+	"path/filepath"
+	"sort"
 
 	"github.com/cascades-fbp/cascades/graph"
 )
 
 func loadGraph(graphfile string) (g *graph.Description, err error) {
-	data, err := ioutil.ReadFile(graphfile)
+	return graph.ParseFile(graphfile)
+}
+
+func graphDir(graphfile string) (string, error) {
+	path, err := filepath.Abs(graphfile)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to read graph definition from file: %s", err.Error())
+		return "", fmt.Errorf("Failed to resolve absolute path for %s: %s", graphfile, err.Error())
 	}
-	if strings.HasSuffix(graphfile, ".fbp") {
-		g, err = graph.ParseFBP(data)
-	} else if strings.HasSuffix(graphfile, ".json") {
-		g, err = graph.ParseJSON(data)
-	} else {
-		return nil, fmt.Errorf("Unsupported graph format (should be .fbp or .json): %s", err.Error())
+	return filepath.Dir(path), nil
+}
+
+// socketEndpoint returns a key of the endpoint used for socket allocation
+func socketEndpoint(e *graph.Endpoint) string {
+	index := 0
+	if e.Index != nil {
+		index = *e.Index
 	}
-	if err != nil {
-		return nil, fmt.Errorf("Failed to parse graph definition: %s", err.Error())
+	return fmt.Sprintf("%s.%s.%v", e.Process, e.Port, index)
+}
+
+// endpointGroups is a disjoint set of endpoints connected to each other
+type endpointGroups map[string]string
+
+func newEndpointGroups() endpointGroups {
+	return endpointGroups{}
+}
+
+func (g endpointGroups) add(endpoint string) {
+	if _, ok := g[endpoint]; !ok {
+		g[endpoint] = endpoint
 	}
+}
 
-	return g, nil
+func (g endpointGroups) find(endpoint string) string {
+	g.add(endpoint)
+	for g[endpoint] != endpoint {
+		g[endpoint] = g[g[endpoint]]
+		endpoint = g[endpoint]
+	}
+	return endpoint
+}
+
+func (g endpointGroups) union(a, b string) {
+	ra, rb := g.find(a), g.find(b)
+	if ra != rb {
+		g[rb] = ra
+	}
+}
+
+// list returns sorted members of every group (groups are ordered by their
+// first member to keep allocation of the sockets stable)
+func (g endpointGroups) list() [][]string {
+	members := map[string][]string{}
+	for endpoint := range g {
+		root := g.find(endpoint)
+		members[root] = append(members[root], endpoint)
+	}
+	groups := [][]string{}
+	for _, m := range members {
+		sort.Strings(m)
+		groups = append(groups, m)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
 }
@@ -0,0 +1,153 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/cascades-fbp/cascades/library"
+)
+
+var portName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Component describes an elementary component to generate
+type Component struct {
+	Name        string
+	Description string
+	Inports     []library.EntryPort
+	Outports    []library.EntryPort
+}
+
+// port is a port description passed to the templates
+type port struct {
+	library.EntryPort
+	Var  string
+	Flag string
+	Ch   string
+}
+
+// reservedCh are channel names used by the generated code itself: channels
+// of such ports get a PortCh suffix (e.g. exitPortCh of EXIT port)
+var reservedCh = map[string]bool{
+	"exitCh": true,
+	"waitCh": true,
+}
+
+// componentData is the data passed to the component templates
+type componentData struct {
+	Name        string
+	Description string
+	Inports     []port
+	Outports    []port
+	Ports       []port
+}
+
+// ParsePort parses a port given as NAME[:type] (type defaults to all)
+func ParsePort(spec string, input bool) (library.EntryPort, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if !portName.MatchString(parts[0]) {
+		return library.EntryPort{}, fmt.Errorf("Invalid port name %s", parts[0])
+	}
+	p := library.EntryPort{
+		Name:        strings.ToUpper(parts[0]),
+		Type:        "all",
+		Description: "Output port for sending IPs",
+		Required:    true,
+	}
+	if input {
+		p.Description = "Input port for receiving IPs"
+	}
+	if len(parts) == 2 && parts[1] != "" {
+		p.Type = parts[1]
+	}
+	return p, nil
+}
+
+// Generate writes doc.go and main.go of the component into a given directory
+func (c *Component) Generate(dir string) error {
+	if len(c.Inports) == 0 && len(c.Outports) == 0 {
+		return fmt.Errorf("Component %s should have at least one port", c.Name)
+	}
+	data, err := c.data()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	t := template.Must(template.New("component").Parse(componentTemplates))
+	for _, name := range []string{"doc.go", "main.go"} {
+		var buf bytes.Buffer
+		if err = t.ExecuteTemplate(&buf, name, data); err != nil {
+			return err
+		}
+		source, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("Failed to generate %s: %s", name, err.Error())
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, name), source, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// data prepares the template data checking the ports for duplicates
+func (c *Component) data() (*componentData, error) {
+	data := &componentData{
+		Name:        path.Base(c.Name),
+		Description: c.Description,
+		Inports:     []port{},
+		Outports:    []port{},
+		Ports:       []port{},
+	}
+	seen := map[string]bool{}
+	add := func(ports []library.EntryPort, target *[]port) error {
+		for _, p := range ports {
+			tp := port{EntryPort: p, Var: identifier(p.Name), Flag: strings.ToLower(p.Name)}
+			tp.Ch = tp.Var + "Ch"
+			if reservedCh[tp.Ch] {
+				tp.Ch = tp.Var + "PortCh"
+			}
+			if seen[tp.Var] || seen[tp.Ch] {
+				return fmt.Errorf("Duplicate port %s", p.Name)
+			}
+			seen[tp.Var] = true
+			seen[tp.Ch] = true
+			*target = append(*target, tp)
+			data.Ports = append(data.Ports, tp)
+		}
+		return nil
+	}
+	if err := add(c.Inports, &data.Inports); err != nil {
+		return nil, err
+	}
+	if err := add(c.Outports, &data.Outports); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// identifier converts a port name (e.g. IN_DATA) to a Go identifier (inData)
+func identifier(name string) string {
+	parts := strings.Split(strings.ToLower(name), "_")
+	id := ""
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if id == "" {
+			id = p
+		} else {
+			id += strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return id
+}
@@ -0,0 +1,149 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/cascades-fbp/cascades/library"
+)
+
+// Graph describes a starter graph chaining a list of library components:
+// the first matching outport of every process is connected to an inport
+// of the next one and the rest required inports get initial IPs to fill in
+type Graph struct {
+	Name       string
+	Components []string
+	Registrar  library.Registrar
+}
+
+// graphProcess is a process of the generated graph
+type graphProcess struct {
+	Name      string
+	Ref       string
+	Entry     library.Entry
+	declared  bool
+	connected map[string]bool
+}
+
+// chainLink is a connection between the neighbour processes
+type chainLink struct {
+	out, in string
+	ok      bool
+}
+
+// label returns the process name declaring the component on first use
+func (p *graphProcess) label() string {
+	if p.declared {
+		return p.Name
+	}
+	p.declared = true
+	return fmt.Sprintf("%s(%s)", p.Name, p.Ref)
+}
+
+// WriteFBP writes the graph in .fbp format
+func (g *Graph) WriteFBP(w io.Writer) error {
+	processes, err := g.processes()
+	if err != nil {
+		return err
+	}
+
+	// chain the processes
+	chain := make([]*chainLink, len(processes)-1)
+	for i := range chain {
+		link := &chainLink{}
+		link.out, link.in, link.ok = chainPorts(processes[i].Entry, processes[i+1].Entry)
+		if link.ok {
+			processes[i+1].connected[strings.TrimSuffix(link.in, "[0]")] = true
+		}
+		chain[i] = link
+	}
+
+	fmt.Fprintf(w, "# %s\n#\n# Starter graph: set the initial IPs marked with TODO and check the connections\n\n", g.Name)
+	for i, p := range processes {
+		for _, port := range p.Entry.Inports {
+			if !port.Required || p.connected[strings.ToUpper(port.Name)] {
+				continue
+			}
+			fmt.Fprintf(w, "# TODO: %s (%s)\n", strings.ToUpper(port.Name), port.Description)
+			fmt.Fprintf(w, "'' -> %s %s\n", strings.ToUpper(port.Name), p.label())
+		}
+		if i == len(chain) {
+			break
+		}
+		next := processes[i+1]
+		if !chain[i].ok {
+			fmt.Fprintf(w, "# TODO: no compatible ports to connect %s and %s\n", p.Name, next.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s -> %s %s\n", p.label(), chain[i].out, chain[i].in, next.label())
+	}
+	for _, p := range processes {
+		if !p.declared {
+			fmt.Fprintf(w, "# TODO: %s(%s) is not connected\n", p.Name, p.Ref)
+		}
+	}
+	return nil
+}
+
+// processes looks up the components and names the processes after them
+func (g *Graph) processes() ([]*graphProcess, error) {
+	if len(g.Components) == 0 {
+		return nil, fmt.Errorf("Graph should use at least one component")
+	}
+	processes := []*graphProcess{}
+	names := map[string]int{}
+	for _, ref := range g.Components {
+		entry, err := g.Registrar.Get(ref)
+		if err != nil {
+			return nil, fmt.Errorf("Component %s not found in the library", ref)
+		}
+		base := path.Base(entry.Name)
+		name := strings.ToUpper(base[:1]) + base[1:]
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s%v", name, names[name])
+		}
+		processes = append(processes, &graphProcess{
+			Name:      name,
+			Ref:       ref,
+			Entry:     entry,
+			connected: map[string]bool{},
+		})
+	}
+	return processes, nil
+}
+
+// chainPorts picks an outport of one component and an inport of another to
+// connect, preferring ports of the same type over the ones accepting all.
+// Returns the port names as used in .fbp (with index if addressable)
+func chainPorts(src, tgt library.Entry) (string, string, bool) {
+	for _, exact := range []bool{true, false} {
+		for _, out := range src.Outports {
+			if strings.EqualFold(out.Name, "err") {
+				continue
+			}
+			for _, in := range tgt.Inports {
+				if compatibleTypes(out.Type, in.Type, exact) {
+					return fbpPort(out), fbpPort(in), true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+func fbpPort(p library.EntryPort) string {
+	if p.Addressable {
+		return strings.ToUpper(p.Name) + "[0]"
+	}
+	return strings.ToUpper(p.Name)
+}
+
+func compatibleTypes(out, in string, exact bool) bool {
+	if strings.EqualFold(out, in) {
+		return true
+	}
+	return !exact && (out == "all" || in == "all")
+}
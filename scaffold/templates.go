@@ -0,0 +1,216 @@
+package scaffold
+
+const componentTemplates = `
+{{define "doc.go"}}package main
+
+import (
+	"github.com/cascades-fbp/cascades/library"
+)
+
+var registryEntry = &library.Entry{
+	Description: {{printf "%q" .Description}},
+	Elementary:  true,
+	Inports: []library.EntryPort{
+{{- range .Inports}}
+		library.EntryPort{
+			Name:        {{printf "%q" .Name}},
+			Type:        {{printf "%q" .Type}},
+			Description: {{printf "%q" .Description}},
+			Required:    true,
+		},
+{{- end}}
+	},
+	Outports: []library.EntryPort{
+{{- range .Outports}}
+		library.EntryPort{
+			Name:        {{printf "%q" .Name}},
+			Type:        {{printf "%q" .Type}},
+			Description: {{printf "%q" .Description}},
+			Required:    true,
+		},
+{{- end}}
+	},
+}
+{{end}}
+
+{{define "main.go"}}package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cascades-fbp/cascades/components/utils"
+{{- if .Inports}}
+	"github.com/cascades-fbp/cascades/runtime"
+{{- end}}
+	zmq "github.com/pebbe/zmq4"
+)
+
+var (
+	// Flags
+{{- range .Ports}}
+	{{.Var}}Endpoint = flag.String("port.{{.Flag}}", "", "Component's {{.Flag}} port endpoint")
+{{- end}}
+	jsonFlag = flag.Bool("json", false, "Print component documentation in JSON")
+	debug    = flag.Bool("debug", false, "Enable debug mode")
+
+	// Internal
+	{{range $i, $p := .Ports}}{{if $i}}, {{end}}{{$p.Var}}Port{{end}} *zmq.Socket
+	{{range $i, $p := .Ports}}{{if $i}}, {{end}}{{$p.Ch}}{{end}} chan bool
+	exitCh chan os.Signal
+	err    error
+)
+
+func main() {
+	flag.Parse()
+
+	if *jsonFlag {
+		doc, _ := registryEntry.JSON()
+		fmt.Println(string(doc))
+		os.Exit(0)
+	}
+
+	utils.SetupLogging(*debug)
+
+	validateArgs()
+
+	// Communication channels
+{{- range .Ports}}
+	{{.Ch}} = make(chan bool)
+{{- end}}
+	exitCh = make(chan os.Signal, 1)
+
+	// Start the communication & processing logic
+	go mainLoop()
+
+	// Wait for the end...
+	signal.Notify(exitCh, os.Interrupt, syscall.SIGTERM)
+	<-exitCh
+
+	log.Println("Done")
+}
+
+// mainLoop initiates all ports and handles the traffic
+func mainLoop() {
+	openPorts()
+	defer closePorts()
+
+	waitCh := make(chan bool)
+	go func() {
+		total := 0
+		for {
+			select {
+{{- range .Ports}}
+			case v := <-{{.Ch}}:
+				if !v {
+					log.Println("{{.Name}} port is closed. Interrupting execution")
+					exitCh <- syscall.SIGTERM
+					break
+				} else {
+					total++
+				}
+{{- end}}
+			}
+
+			if total >= {{len .Ports}} && waitCh != nil {
+				waitCh <- true
+			}
+		}
+	}()
+
+	log.Println("Waiting for port connections to establish... ")
+	select {
+	case <-waitCh:
+		log.Println("Ports connected")
+		waitCh = nil
+	case <-time.Tick(30 * time.Second):
+		log.Println("Timeout: port connections were not established within provided interval")
+		exitCh <- syscall.SIGTERM
+		return
+	}
+
+	log.Println("Started...")
+{{- if eq (len .Inports) 1}}
+{{- $in := index .Inports 0}}
+	for {
+		ip, err := {{$in.Var}}Port.RecvMessageBytes(0)
+		if err != nil {
+			continue
+		}
+		if !runtime.IsValidIP(ip) {
+			continue
+		}
+		// TODO: process the IP received on {{$in.Name}} port
+{{- range .Outports}}
+		{{.Var}}Port.SendMessage(ip)
+{{- end}}
+	}
+{{- else if .Inports}}
+	poller := zmq.NewPoller()
+{{- range .Inports}}
+	poller.Add({{.Var}}Port, zmq.POLLIN)
+{{- end}}
+	for {
+		results, err := poller.Poll(-1)
+		if err != nil {
+			continue
+		}
+		for _, r := range results {
+			ip, err := r.Socket.RecvMessageBytes(0)
+			if err != nil {
+				continue
+			}
+			if !runtime.IsValidIP(ip) {
+				continue
+			}
+			switch r.Socket {
+{{- range .Inports}}
+			case {{.Var}}Port:
+				// TODO: process the IP received on {{.Name}} port
+{{- end}}
+			}
+		}
+	}
+{{- else}}
+	// TODO: generate IPs and send them to the output ports
+	select {}
+{{- end}}
+}
+
+// validateArgs checks all required flags
+func validateArgs() {
+{{- range .Ports}}
+	if *{{.Var}}Endpoint == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+{{- end}}
+}
+
+// openPorts create ZMQ sockets and start socket monitoring loops
+func openPorts() {
+{{- range .Inports}}
+	{{.Var}}Port, err = utils.CreateInputPort("{{$.Name}}.{{.Flag}}", *{{.Var}}Endpoint, {{.Ch}})
+	utils.AssertError(err)
+{{end}}
+{{- range .Outports}}
+	{{.Var}}Port, err = utils.CreateOutputPort("{{$.Name}}.{{.Flag}}", *{{.Var}}Endpoint, {{.Ch}})
+	utils.AssertError(err)
+{{end -}}
+}
+
+// closePorts closes all active ports and terminates ZMQ context
+func closePorts() {
+	log.Println("Closing ports...")
+{{- range .Ports}}
+	{{.Var}}Port.Close()
+{{- end}}
+	zmq.Term()
+}
+{{end}}
+`